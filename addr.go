@@ -1,6 +1,18 @@
 package goapollo
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+)
+
+// clientQuery 获取配置和长轮询通知请求中用于匹配灰度规则的 ip 和 label 参数.
+func clientQuery(ip, label string) string {
+	query := "&ip=" + url.QueryEscape(ip)
+	if label != "" {
+		query += "&label=" + url.QueryEscape(label)
+	}
+	return query
+}
 
 func getConfigfiles(host, appId, cluster, namespace, ip string) string {
 	return fmt.Sprintf("%s/configfiles/json/%s/%s/%s?ip=%s",
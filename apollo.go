@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -21,6 +22,13 @@ const (
 	defaultNamespace  = "application"
 
 	defaultNotificationId = -1
+
+	defaultRefreshInterval = 5 * time.Minute
+
+	defaultCluster         = "default"
+	defaultTimeout         = 30 * time.Second
+	defaultLongPollTimeout = 90 * time.Second
+	defaultEventBufferSize = 100
 )
 
 type result struct {
@@ -32,110 +40,345 @@ type result struct {
 }
 
 type Client struct {
-	host         string
+	listenerId   uint64
+	delivered    uint64
+	dropped      uint64
+	coalesced    uint64
+	services     *configServices
 	appId        string
 	cluster      string
 	cacheDir     string
 	ip           string
+	label        string
+	secret       string
+	logger       ILogger
 	caches       *namespaceCache
 	notification INotification
 	rmx          *sync.RWMutex
 	eventCh      chan *ChangeEvent
 	done         uint32
+	ctx          context.Context
 	cancel       context.CancelFunc
-	releaseRepo  *sync.Map
+	wg           *sync.WaitGroup
+	closeOnce    *sync.Once
 	client       *http.Client
+
+	serviceRefreshInterval time.Duration
+	namespaces             []string
+	readyPolicy            ReadyPolicy
+	readyTimeout           time.Duration
+	listSeparator          string
+	bindings               []*Binding
+	listeners              []*changeListener
+	deliveryMode           DeliveryMode
+	refreshInterval        time.Duration
+	offline                bool
+	watchInterval          time.Duration
+	decryptor              Decryptor
+	serializer             Serializer
+	plaintexts             *sync.Map
+	decryptMux             *sync.RWMutex
+	backoff                *backoffState
+	pendingMux             *sync.Mutex
+	pending                map[string]*ChangeEvent
+	pendingQueue           []string
+	inflight               string
+	pendingSignal          chan struct{}
 }
 
+// New 使用固定的配置服务地址创建客户端.
 func New(host, appId, cluster string) *Client {
-	var netTransport = &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   90 * time.Second, //连接超时时间
-			KeepAlive: 90 * time.Second, //连接保持超时时间
-		}).DialContext,
-		MaxIdleConns:        20,                //client对与所有host最大空闲连接数总和
-		IdleConnTimeout:     100 * time.Second, //空闲连接在连接池中的超时时间
-		TLSHandshakeTimeout: 100 * time.Second, //TLS安全连接握手超时时间
+	return newClient(Config{Host: host, AppId: appId, Cluster: cluster})
+}
+
+// NewWithMetaServer 创建通过 Meta Server 发现配置服务的客户端，多个 Meta Server 可用逗号分隔.
+// 配置服务不可用时会自动切换到其他实例，并定时刷新配置服务列表.
+func NewWithMetaServer(meta, appId, cluster string) *Client {
+	return newClient(Config{MetaServer: meta, AppId: appId, Cluster: cluster})
+}
+
+// NewWithConfig 使用配置创建客户端，opts 会在校验前依次修改配置，配置无效时返回错误.
+// Namespaces 中的命名空间会使用默认序列化器添加.
+func NewWithConfig(conf Config, opts ...Option) (*Client, error) {
+	for _, opt := range opts {
+		if err := opt(&conf); err != nil {
+			return nil, err
+		}
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	c := newClient(conf)
+	for _, namespace := range conf.Namespaces {
+		c.AddNamespace(namespace)
+	}
+	return c, nil
+}
+
+func newClient(conf Config) *Client {
+	if conf.Cluster == "" {
+		conf.Cluster = defaultCluster
+	}
+	if conf.CacheDir == "" {
+		conf.CacheDir = os.TempDir()
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+	if conf.LongPollTimeout <= 0 {
+		conf.LongPollTimeout = defaultLongPollTimeout
 	}
+	if conf.EventBufferSize <= 0 {
+		conf.EventBufferSize = defaultEventBufferSize
+	}
+	if conf.RefreshInterval == 0 {
+		conf.RefreshInterval = defaultRefreshInterval
+	}
+	if conf.ReadyTimeout <= 0 {
+		conf.ReadyTimeout = defaultReadyTimeout
+	}
+	if conf.IP == "" {
+		conf.IP = selectIP(localAddrs(), conf.IPPreference)
+	}
+	conf.Label = joinLabels(conf.Label)
+
+	var services *configServices
+	if conf.MetaServer != "" {
+		services = newMetaServices(conf.MetaServer, conf.AppId)
+	} else {
+		services = newStaticServices(conf.Host)
+	}
+	services.logger = conf.Logger
+
+	client, pollClient := newHTTPClients(conf)
+
+	notification := newNotificationRepo(services, conf.AppId, conf.Cluster)
+	notification.client = pollClient
+	notification.secret = conf.Secret
+	notification.ip = conf.IP
+	notification.label = conf.Label
+	notification.logger = conf.Logger
+
+	caches := newNamespaceCache()
+	caches.logger = conf.Logger
+
 	return &Client{
-		host:         host,
-		appId:        appId,
-		cluster:      cluster,
-		cacheDir:     os.TempDir(),
-		caches:       newNamespaceCache(),
-		notification: newNotificationRepo(host, appId, cluster),
+		services:     services,
+		appId:        conf.AppId,
+		cluster:      conf.Cluster,
+		cacheDir:     conf.CacheDir,
+		ip:           conf.IP,
+		label:        conf.Label,
+		secret:       conf.Secret,
+		logger:       conf.Logger,
+		caches:       caches,
+		notification: notification,
 		rmx:          &sync.RWMutex{},
-		eventCh:      make(chan *ChangeEvent, 100),
-		releaseRepo:  &sync.Map{},
-		client: &http.Client{
-			Timeout:   time.Second * 30,
-			Transport: netTransport,
-		},
+		eventCh:      make(chan *ChangeEvent, conf.EventBufferSize),
+		ctx:          context.Background(),
+		wg:           &sync.WaitGroup{},
+		closeOnce:    &sync.Once{},
+		client:       client,
+
+		serviceRefreshInterval: defaultServiceRefreshInterval,
+		readyPolicy:            conf.ReadyPolicy,
+		readyTimeout:           conf.ReadyTimeout,
+		listSeparator:          defaultListSeparator,
+		deliveryMode:           conf.DeliveryMode,
+		refreshInterval:        conf.RefreshInterval,
+		offline:                conf.Offline,
+		watchInterval:          conf.WatchInterval,
+		decryptor:              conf.Decryptor,
+		serializer:             conf.Serializer,
+		plaintexts:             &sync.Map{},
+		decryptMux:             &sync.RWMutex{},
+		backoff:                newBackoffState(DefaultBackoff()),
+		pendingMux:             &sync.Mutex{},
+		pending:                make(map[string]*ChangeEvent),
+		pendingSignal:          make(chan struct{}, 1),
+	}
+}
+
+// newHTTPClients 创建获取配置和长轮询通知使用的 HTTP 客户端.
+func newHTTPClients(conf Config) (client *http.Client, pollClient *http.Client) {
+	if conf.HTTPClient != nil {
+		c := *conf.HTTPClient
+		c.Timeout = conf.LongPollTimeout
+		return conf.HTTPClient, &c
 	}
+	transport := conf.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   90 * time.Second, //连接超时时间
+				KeepAlive: 90 * time.Second, //连接保持超时时间
+			}).DialContext,
+			MaxIdleConns:        20,                //client对与所有host最大空闲连接数总和
+			IdleConnTimeout:     100 * time.Second, //空闲连接在连接池中的超时时间
+			TLSHandshakeTimeout: 100 * time.Second, //TLS安全连接握手超时时间
+		}
+	}
+	return &http.Client{Timeout: conf.Timeout, Transport: transport},
+		&http.Client{Timeout: conf.LongPollTimeout, Transport: transport}
+}
+
+func (c *Client) log() ILogger {
+	return loggerOrDefault(c.logger)
 }
 
 func (c *Client) SetCacheDir(dir string) {
 	c.cacheDir = dir
 }
 
+// SetServiceRefreshInterval 设置从 Meta Server 刷新配置服务列表的间隔.
+func (c *Client) SetServiceRefreshInterval(interval time.Duration) {
+	c.serviceRefreshInterval = interval
+}
+
+// SetRefreshInterval 设置定时全量拉取所有命名空间的间隔，用于弥补丢失的变更通知，默认 5 分钟，小于等于 0 时关闭.
+func (c *Client) SetRefreshInterval(interval time.Duration) {
+	c.refreshInterval = interval
+}
+
+// SetBackoff 设置长轮询通知和配置拉取失败后的退避策略.
+func (c *Client) SetBackoff(backoff Backoff) {
+	c.backoff.setPolicy(backoff)
+	if repo, ok := c.notification.(*notificationRepo); ok {
+		repo.backoff.setPolicy(backoff)
+	}
+}
+
+// SetReadyPolicy 设置初始加载失败时的就绪策略，默认允许使用本地备份.
+func (c *Client) SetReadyPolicy(policy ReadyPolicy) {
+	c.readyPolicy = policy
+}
+
+// SetReadyTimeout 设置 Run 等待初始加载完成的最长时间，小于等于 0 时不限制.
+func (c *Client) SetReadyTimeout(timeout time.Duration) {
+	c.readyTimeout = timeout
+}
+
+// SetClientIp 设置用于匹配灰度规则的客户端 IP，覆盖自动选择的地址，需要在 Run 之前调用.
 func (c *Client) SetClientIp(ip string) {
 	c.ip = ip
+	if repo, ok := c.notification.(*notificationRepo); ok {
+		repo.ip = ip
+	}
+}
+
+// SetLabel 设置用于匹配灰度规则的客户端标签，需要在 Run 之前调用.
+func (c *Client) SetLabel(labels ...string) {
+	c.label = joinLabels(labels...)
+	if repo, ok := c.notification.(*notificationRepo); ok {
+		repo.label = c.label
+	}
+}
+
+// SetAccessKeySecret 设置访问密钥，设置后所有配置和通知请求都会携带签名.
+func (c *Client) SetAccessKeySecret(secret string) {
+	c.secret = secret
+	if repo, ok := c.notification.(*notificationRepo); ok {
+		repo.secret = secret
+	}
 }
 
 func (c *Client) preload(namespace string) {
-	err := c.caches.load(namespace, filepath.Join(c.cacheDir, c.appId, namespace))
+	_, err := c.caches.load(namespace, filepath.Join(c.cacheDir, c.appId, namespace))
 	if err != nil {
-		logger.Printf("解析备份文件失败 -> %s", err)
+		c.log().Printf("解析备份文件失败 -> %s", err)
 	}
 }
 
+// sync 从配置服务拉取指定命名空间的最新配置并更新缓存，配置未变化时返回 nil.
 func (c *Client) sync(namespace string) (*ChangeEvent, error) {
-	configUrl := fmt.Sprintf("%s/configs/%s/%s/%s?releaseKey=%s&ip=%s",
-		c.host,
+	result, err := c.fetch(c.cluster, namespace, c.GetReleaseKey(namespace))
+	if err != nil || result == nil {
+		return nil, err
+	}
+	// 配置解析成功后才更新版本号，否则后续拉取会因版本号未变化而得到 304
+	event, err := c.caches.store(*result)
+	if err != nil {
+		return nil, err
+	}
+	c.caches.setReleaseKey(result.NamespaceName, result.ReleaseKey)
+
+	return event, nil
+}
+
+// fetch 从配置服务获取指定集群下的命名空间，版本号未变化时返回 nil，配置服务不可用时依次尝试其他实例.
+func (c *Client) fetch(cluster, namespace, releaseKey string) (*result, error) {
+	var lastErr error
+	for i := 0; i == 0 || i < c.services.size(); i++ {
+		host, err := c.services.current()
+		if err != nil {
+			return nil, err
+		}
+		result, unavailable, err := c.fetchFrom(host, cluster, namespace, releaseKey)
+		if !unavailable {
+			c.services.succeed(host)
+			return result, err
+		}
+		lastErr = err
+		c.services.failover(host)
+	}
+	return nil, lastErr
+}
+
+func (c *Client) fetchFrom(host, cluster, namespace, releaseKey string) (res *result, unavailable bool, err error) {
+	configUrl := fmt.Sprintf("%s/configs/%s/%s/%s?releaseKey=%s%s",
+		host,
 		url.QueryEscape(c.appId),
-		url.QueryEscape(c.cluster),
+		url.QueryEscape(cluster),
 		url.QueryEscape(namespace),
-		c.GetReleaseKey(namespace),
-		c.ip,
+		url.QueryEscape(releaseKey),
+		clientQuery(c.ip, c.label),
 	)
-	logger.Printf("正在获取最新配置 -> %s", configUrl)
+	c.log().Printf("正在获取最新配置 -> %s", configUrl)
 	req, err := http.NewRequest("GET", configUrl, nil)
 	if err != nil {
-		logger.Printf("构建 Request 出错 -> %s", err)
-		return nil, err
+		c.log().Printf("构建 Request 出错 -> %s", err)
+		return nil, false, err
 	}
+	req = req.WithContext(c.ctx)
+	signRequest(req, c.appId, c.secret)
 
 	resp, err := c.client.Do(req)
 
 	if err != nil {
-		return nil, nil
+		c.log().Printf("获取最新配置失败 -> %s - %s", configUrl, err)
+		return nil, true, err
 	}
 	if resp.StatusCode == http.StatusNotModified {
-		return nil, nil
+		_ = resp.Body.Close()
+		return nil, false, nil
 	}
 	body, err := ioutil.ReadAll(resp.Body)
 
 	_ = resp.Body.Close()
 
+	if err != nil {
+		return nil, true, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		logger.Printf("发起通知请求失败 -> %s - %d - %s ", configUrl, resp.StatusCode, string(body))
-		return nil, err
-
+		c.log().Printf("发起通知请求失败 -> %s - %d - %s ", configUrl, resp.StatusCode, string(body))
+		return nil, resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("服务器响应失败 -> %d - %s", resp.StatusCode, string(body))
 	}
-	logger.Printf("获取最新配置成功 -> %s - %s", configUrl, string(body))
+	c.log().Printf("获取最新配置成功 -> %s - %s", configUrl, string(body))
 	var result result
 	if err := json.Unmarshal(body, &result); err != nil {
-		logger.Printf("解析服务端响应值失败 -> %s - %s - %s", configUrl, string(body), err)
-		return nil, err
+		c.log().Printf("解析服务端响应值失败 -> %s - %s - %s", configUrl, string(body), err)
+		return nil, false, err
 	}
-	c.releaseRepo.Store(result.NamespaceName, result.ReleaseKey)
-
-	return c.caches.store(result), nil
+	return &result, false, nil
 }
 
 //AddNamespace 使用默认序列化器添加命名空间
 func (c *Client) AddNamespace(name string) *Client {
-	c.AddNamespaceWithSerializer(name, NewJsonSerializer())
+	serializer := c.serializer
+	if serializer == nil {
+		serializer = NewJsonSerializer()
+	}
+	c.AddNamespaceWithSerializer(name, serializer)
 	return c
 }
 
@@ -147,45 +390,160 @@ func (c *Client) AddNamespaceWithSerializer(namespace string, serializer Seriali
 }
 
 func (c *Client) AddNamespaceWithSerializerWithPath(namespace string, serializer Serializer, filename string) *Client {
+	c.addNamespace(namespace)
 	c.notification.AddNamespace(namespace)
 	c.caches.addSerializer(namespace, serializer)
-	err := c.caches.load(namespace, filename)
+	_, err := c.caches.load(namespace, filename)
 	if err != nil {
-		logger.Printf("解析备份文件失败 -> %s", err)
+		c.log().Printf("解析备份文件失败 -> %s", err)
+	}
+	// 从备份恢复通知 ID，配置未变更时重启后的长轮询和拉取都只会得到 304
+	if id, ok := c.caches.notificationId(namespace); ok {
+		if repo, ok := c.notification.(*notificationRepo); ok {
+			repo.setNotificationId(namespace, id)
+		}
 	}
 	return c
 }
 
+func (c *Client) addNamespace(namespace string) {
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+	for _, name := range c.namespaces {
+		if name == namespace {
+			return
+		}
+	}
+	c.namespaces = append(c.namespaces, namespace)
+}
+
+func (c *Client) getNamespaces() []string {
+	c.rmx.RLock()
+	defer c.rmx.RUnlock()
+	namespaces := make([]string, len(c.namespaces))
+	copy(namespaces, c.namespaces)
+	return namespaces
+}
+
+// refresh 拉取指定命名空间的最新配置，配置有变化时发送通知并保存备份.
+func (c *Client) refresh(namespace string) error {
+	if c.offline {
+		return c.reload(namespace)
+	}
+	event, err := c.sync(namespace)
+	if err != nil {
+		return err
+	}
+	if event != nil {
+		if len(event.Changes) > 0 {
+			c.log().Printf("事件通知 -> %+v", event)
+			c.publish(event)
+		}
+		_ = c.caches.dump(namespace)
+	}
+	return nil
+}
+
+// refreshWithBackoff 拉取失败时按命名空间的退避策略等待后重试，最多重试 retries 次，成功后重置该命名空间的退避状态.
+func (c *Client) refreshWithBackoff(ctx context.Context, namespace string, retries int) error {
+	for attempt := 0; ; attempt++ {
+		err := c.refresh(namespace)
+		if err == nil {
+			c.backoff.reset(namespace)
+			return nil
+		}
+		delay := c.backoff.next(namespace)
+		if attempt >= retries {
+			return err
+		}
+		c.log().Printf("同步最新配置失败，%s 后重试 -> %s - %s - %s - %s", delay, c.appId, c.cluster, namespace, err)
+		if !sleep(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// refreshAll 使用当前版本号拉取所有命名空间，服务端未变更时返回 304，仍在退避等待中的命名空间本次跳过.
+func (c *Client) refreshAll(ctx context.Context) {
+	for _, namespace := range c.getNamespaces() {
+		if c.backoff.waiting(namespace) {
+			continue
+		}
+		if err := c.refreshWithBackoff(ctx, namespace, 0); err != nil {
+			c.log().Printf("定时同步配置失败 -> %s - %s - %s - %s", c.appId, c.cluster, namespace, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// publish 发送变更事件，引用了变更配置的占位符所在的配置也会一并发送.
+func (c *Client) publish(event *ChangeEvent) {
+	for _, event := range c.withDependents(event) {
+		c.rebind(event.Namespace)
+		c.notifyListeners(event)
+		c.deliver(event)
+	}
+}
+
+// Run 拉取所有已添加的命名空间后启动变更监听，初始加载结果不满足就绪策略时返回 *LoadError.
 func (c *Client) Run(ctx context.Context) error {
-	if atomic.LoadUint32(&c.done) == 1 {
+	if !atomic.CompareAndSwapUint32(&c.done, 0, 1) {
 		return errors.New("apollo already running")
 	}
 	ctx1, cancel := context.WithCancel(ctx)
+	c.ctx = ctx1
 	c.cancel = cancel
+	if c.deliveryMode == DeliveryCoalesce {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.forward(ctx1)
+		}()
+	}
+	if err := c.load(ctx1); err != nil {
+		cancel()
+		c.wg.Wait()
+		atomic.StoreUint32(&c.done, 0)
+		return err
+	}
+	if c.offline {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.watchFiles(ctx1)
+		}()
+		return nil
+	}
+	go c.services.watch(ctx1, c.serviceRefreshInterval)
+	notifications := c.notification.Watch()
+	c.wg.Add(1)
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Printf("出现未处理异常 -> %s", err)
+				c.log().Printf("出现未处理异常 -> %s", err)
 			}
-			logger.Printf("通知变更监听已退出")
+			c.log().Printf("通知变更监听已退出")
+			c.wg.Done()
 		}()
 
+		var refreshCh <-chan time.Time
+		if c.refreshInterval > 0 {
+			ticker := time.NewTicker(c.refreshInterval)
+			defer ticker.Stop()
+			refreshCh = ticker.C
+		}
+
 		for {
 			select {
-			case notify := <-c.notification.Watch():
-
-				if event, err := c.sync(notify.NamespaceName); err != nil {
-					logger.Printf("同步最新配置失败 -> %s - %s - %s - %s", c.appId, c.cluster, notify.NamespaceName, err)
-					break
-				} else if event != nil {
-					logger.Printf("事件通知 -> %+v", event)
-					select {
-					case c.eventCh <- event:
-					default:
-					}
-					_ = c.caches.dump(notify.NamespaceName)
+			case notify := <-notifications:
+				c.caches.setNotificationId(notify.NamespaceName, notify.NotificationId)
+				if err := c.refreshWithBackoff(ctx1, notify.NamespaceName, c.backoff.retries()); err != nil {
+					c.log().Printf("同步最新配置失败 -> %s - %s - %s - %s", c.appId, c.cluster, notify.NamespaceName, err)
 				}
-
+			case <-refreshCh:
+				c.refreshAll(ctx1)
 			case <-ctx1.Done():
 				return
 			}
@@ -194,49 +552,72 @@ func (c *Client) Run(ctx context.Context) error {
 	return nil
 }
 
+// Close 停止变更监听，等待后台任务退出后保存备份并关闭事件通道.
 func (c *Client) Close() error {
-	c.rmx.Lock()
-	defer c.rmx.Unlock()
-	_ = c.caches.save()
-
 	if c.cancel != nil {
 		c.cancel()
 	}
-	c.caches = newNamespaceCache()
 	_ = c.notification.Close()
-	close(c.eventCh)
+	c.wg.Wait()
+
+	c.rmx.Lock()
+	defer c.rmx.Unlock()
+	// 离线模式下备份文件由使用者维护，不回写
+	if !c.offline {
+		_ = c.caches.save()
+	}
+
+	c.caches = newNamespaceCache()
+	c.caches.logger = c.logger
+	c.closeOnce.Do(func() {
+		close(c.eventCh)
+	})
 
 	return nil
 }
 
+// Dump 使用指定的序列化器将命名空间按备份文件的格式写入 w，写入的内容可以直接作为离线模式的备份文件.
+func (c *Client) Dump(namespace string, serializer Serializer, w io.Writer) error {
+	body, err := c.caches.export(namespace, serializer)
+	if err != nil {
+		return err
+	}
+	if !editable(serializer) {
+		body = encodeBackup(body)
+	}
+	_, err = w.Write(body)
+	return err
+}
+
 func (c *Client) WatchUpdate() <-chan *ChangeEvent {
 	return c.eventCh
 }
 
 // GetReleaseKey 获取指定命名空间的版本号.
 func (c *Client) GetReleaseKey(namespace string) string {
-	if releaseKey, ok := c.releaseRepo.Load(namespace); ok {
-		return releaseKey.(string)
-	}
-
-	return ""
+	return c.caches.releaseKey(namespace)
 }
 
 //GetValue 获取默认命名空间的指定键值.
 func (c *Client) GetValue(key string) (val string, exist bool) {
-	val, exist = c.caches.get(defaultNamespace, key)
+	val, exist = c.value(defaultNamespace, key)
 	return
 }
 
 //GetValueWithNamespace 获取指定命名空间的指定键值.
 func (c *Client) GetValueWithNamespace(namespace, key string) (val string, exist bool) {
-	val, exist = c.caches.get(namespace, key)
+	val, exist = c.value(namespace, key)
 	return
 }
 
-//GetContentWithNamespace 获取指定命名空间的内容.
+// value 是所有取值方法的统一入口，返回解密并解析占位符后的值.
+func (c *Client) value(namespace, key string) (string, bool) {
+	return c.resolveValue(namespace, key, c.decrypted)
+}
+
+//GetContentWithNamespace 获取指定命名空间的内容，yaml 和 json 等格式返回原始文档.
 func (c *Client) GetContentWithNamespace(namespace string) (val string, exist bool) {
-	val, exist = c.caches.get(namespace, "content")
+	val, exist = c.caches.content(namespace)
 	return
 }
 
@@ -0,0 +1,387 @@
+// Package apollotest 提供进程内的 Apollo 配置服务，用于在单元测试中验证客户端的真实行为.
+//
+// Server 实现了获取配置、获取配置文件、长轮询通知和服务发现接口，支持发布、删除配置以及灰度发布：
+//
+//	server := apollotest.NewServer()
+//	defer server.Close()
+//	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+//
+//	c := goapollo.New(server.URL, "SampleApp", "default")
+package apollotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCluster         = "default"
+	defaultLongPollTimeout = 60 * time.Second
+)
+
+// GrayRule 灰度规则，客户端 IP 或标签命中任意一项时使用灰度配置，客户端的多个标签用逗号分隔.
+type GrayRule struct {
+	IPs    []string
+	Labels []string
+}
+
+func (r *GrayRule) match(ip, label string) bool {
+	for _, v := range r.IPs {
+		if v == ip && ip != "" {
+			return true
+		}
+	}
+	for _, item := range strings.Split(label, ",") {
+		for _, v := range r.Labels {
+			if v == item && item != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type release struct {
+	configurations map[string]string
+	releaseKey     string
+}
+
+type namespace struct {
+	release
+	notificationId int
+	gray           *GrayRule
+	grayRelease    *release
+}
+
+// Server 进程内的 Apollo 配置服务.
+type Server struct {
+	// URL 配置服务和 Meta Server 的地址.
+	URL string
+	// LongPollTimeout 长轮询没有变更时挂起的时间，默认 60 秒，需要在发起请求前设置.
+	LongPollTimeout time.Duration
+
+	server         *httptest.Server
+	mux            *sync.Mutex
+	namespaces     map[string]*namespace
+	notificationId int
+	releaseId      int
+	changed        chan struct{}
+	closed         chan struct{}
+	closeOnce      *sync.Once
+}
+
+// NewServer 创建并启动配置服务，使用完毕后需要调用 Close.
+func NewServer() *Server {
+	s := &Server{
+		LongPollTimeout: defaultLongPollTimeout,
+		mux:             &sync.Mutex{},
+		namespaces:      make(map[string]*namespace),
+		changed:         make(chan struct{}),
+		closed:          make(chan struct{}),
+		closeOnce:       &sync.Once{},
+	}
+	handler := http.NewServeMux()
+	handler.HandleFunc("/configs/", s.handleConfigs)
+	handler.HandleFunc("/configfiles/json/", s.handleConfigFiles)
+	handler.HandleFunc("/notifications/v2", s.handleNotifications)
+	handler.HandleFunc("/services/config", s.handleServices)
+
+	s.server = httptest.NewServer(handler)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close 结束挂起的长轮询并关闭服务.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+	s.server.Close()
+}
+
+// Publish 发布命名空间的全部配置，未发布过的命名空间会自动创建.
+func (s *Server) Publish(appId, cluster, namespaceName string, configurations map[string]string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ns := s.namespace(appId, cluster, namespaceName)
+	ns.release = s.newRelease(configurations)
+	s.notify(ns)
+}
+
+// SetValue 修改命名空间的单个配置并发布.
+func (s *Server) SetValue(appId, cluster, namespaceName, key, value string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ns := s.namespace(appId, cluster, namespaceName)
+	configurations := copyMap(ns.configurations)
+	configurations[key] = value
+	ns.release = s.newRelease(configurations)
+	s.notify(ns)
+}
+
+// DeleteValue 删除命名空间的单个配置并发布.
+func (s *Server) DeleteValue(appId, cluster, namespaceName, key string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ns := s.namespace(appId, cluster, namespaceName)
+	configurations := copyMap(ns.configurations)
+	delete(configurations, key)
+	ns.release = s.newRelease(configurations)
+	s.notify(ns)
+}
+
+// DeleteNamespace 删除命名空间，之后获取该命名空间的配置返回 404.
+func (s *Server) DeleteNamespace(appId, cluster, namespaceName string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.namespaces, key(appId, cluster, namespaceName))
+	s.broadcast()
+}
+
+// GrayRelease 灰度发布，命中规则的客户端获取到的配置为主版本与 configurations 合并后的结果.
+func (s *Server) GrayRelease(appId, cluster, namespaceName string, rule GrayRule, configurations map[string]string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ns := s.namespace(appId, cluster, namespaceName)
+	merged := copyMap(ns.configurations)
+	for k, v := range configurations {
+		merged[k] = v
+	}
+	gray := s.newRelease(merged)
+	ns.gray = &rule
+	ns.grayRelease = &gray
+	s.notify(ns)
+}
+
+// AbandonGray 放弃灰度发布，所有客户端恢复使用主版本配置.
+func (s *Server) AbandonGray(appId, cluster, namespaceName string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	ns, ok := s.namespaces[key(appId, cluster, namespaceName)]
+	if !ok || ns.gray == nil {
+		return
+	}
+	ns.gray = nil
+	ns.grayRelease = nil
+	s.notify(ns)
+}
+
+// ReleaseKey 获取命名空间主版本的版本号.
+func (s *Server) ReleaseKey(appId, cluster, namespaceName string) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if ns, ok := s.namespaces[key(appId, cluster, namespaceName)]; ok {
+		return ns.releaseKey
+	}
+	return ""
+}
+
+// namespace 获取命名空间，不存在时创建，调用方需持有锁.
+func (s *Server) namespace(appId, cluster, namespaceName string) *namespace {
+	k := key(appId, cluster, namespaceName)
+	ns, ok := s.namespaces[k]
+	if !ok {
+		ns = &namespace{notificationId: -1, release: release{configurations: map[string]string{}}}
+		s.namespaces[k] = ns
+	}
+	return ns
+}
+
+// lookup 查找客户端请求的命名空间，指定集群不存在时使用默认集群，调用方需持有锁.
+func (s *Server) lookup(appId, cluster, namespaceName string) (*namespace, bool) {
+	if ns, ok := s.namespaces[key(appId, cluster, namespaceName)]; ok {
+		return ns, true
+	}
+	ns, ok := s.namespaces[key(appId, defaultCluster, namespaceName)]
+	return ns, ok
+}
+
+func (s *Server) newRelease(configurations map[string]string) release {
+	s.releaseId++
+	return release{
+		configurations: copyMap(configurations),
+		releaseKey:     fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), s.releaseId),
+	}
+}
+
+// notify 更新命名空间的通知 ID 并唤醒挂起的长轮询，调用方需持有锁.
+func (s *Server) notify(ns *namespace) {
+	s.notificationId++
+	ns.notificationId = s.notificationId
+	s.broadcast()
+}
+
+func (s *Server) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// current 获取客户端 IP 和标签对应的配置版本.
+func (ns *namespace) current(ip, label string) release {
+	if ns.gray != nil && ns.gray.match(ip, label) {
+		return *ns.grayRelease
+	}
+	return ns.release
+}
+
+type configResult struct {
+	AppId          string            `json:"appId"`
+	Cluster        string            `json:"cluster"`
+	NamespaceName  string            `json:"namespaceName"`
+	Configurations map[string]string `json:"configurations"`
+	ReleaseKey     string            `json:"releaseKey"`
+}
+
+// handleConfigs 处理 /configs/{appId}/{cluster}/{namespace}，版本号未变化时返回 304.
+func (s *Server) handleConfigs(w http.ResponseWriter, r *http.Request) {
+	appId, cluster, namespaceName, ok := parsePath(r.URL.Path, "/configs/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	s.mux.Lock()
+	ns, ok := s.lookup(appId, cluster, namespaceName)
+	var current release
+	if ok {
+		current = ns.current(query.Get("ip"), query.Get("label"))
+	}
+	s.mux.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if query.Get("releaseKey") == current.releaseKey {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, configResult{
+		AppId:          appId,
+		Cluster:        cluster,
+		NamespaceName:  namespaceName,
+		Configurations: current.configurations,
+		ReleaseKey:     current.releaseKey,
+	})
+}
+
+// handleConfigFiles 处理 /configfiles/json/{appId}/{cluster}/{namespace}，返回配置的键值对.
+func (s *Server) handleConfigFiles(w http.ResponseWriter, r *http.Request) {
+	appId, cluster, namespaceName, ok := parsePath(r.URL.Path, "/configfiles/json/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	s.mux.Lock()
+	ns, ok := s.lookup(appId, cluster, namespaceName)
+	var current release
+	if ok {
+		current = ns.current(query.Get("ip"), query.Get("label"))
+	}
+	s.mux.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, current.configurations)
+}
+
+type notification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationId int    `json:"notificationId"`
+}
+
+// handleNotifications 处理长轮询通知，客户端的通知 ID 落后时立即返回，否则挂起直到有变更或超时返回 304.
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	appId, cluster := query.Get("appId"), query.Get("cluster")
+	var requests []notification
+	if err := json.Unmarshal([]byte(query.Get("notifications")), &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timer := time.NewTimer(s.LongPollTimeout)
+	defer timer.Stop()
+	for {
+		var updates []notification
+		s.mux.Lock()
+		for _, item := range requests {
+			if ns, ok := s.lookup(appId, cluster, item.NamespaceName); ok && ns.notificationId > item.NotificationId {
+				updates = append(updates, notification{NamespaceName: item.NamespaceName, NotificationId: ns.notificationId})
+			}
+		}
+		changed := s.changed
+		s.mux.Unlock()
+
+		if len(updates) > 0 {
+			writeJSON(w, updates)
+			return
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+}
+
+type serviceDTO struct {
+	AppName     string `json:"appName"`
+	InstanceId  string `json:"instanceId"`
+	HomepageUrl string `json:"homepageUrl"`
+}
+
+// handleServices 处理 Meta Server 的服务发现请求，返回当前服务的地址.
+func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
+	u, _ := url.Parse(s.URL)
+	writeJSON(w, []serviceDTO{{
+		AppName:     "APOLLO-CONFIGSERVICE",
+		InstanceId:  u.Host,
+		HomepageUrl: s.URL + "/",
+	}})
+}
+
+func parsePath(path, prefix string) (appId, cluster, namespaceName string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	for i, part := range parts {
+		if part, err := url.PathUnescape(part); err == nil {
+			parts[i] = part
+		}
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func copyMap(m map[string]string) map[string]string {
+	values := make(map[string]string, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	return values
+}
+
+func key(appId, cluster, namespaceName string) string {
+	return appId + "+" + cluster + "+" + namespaceName
+}
@@ -0,0 +1,130 @@
+package apollotest_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lifei6671/goapollo"
+	"github.com/lifei6671/goapollo/apollotest"
+)
+
+func newClient(t *testing.T, server *apollotest.Server, opts ...goapollo.Option) (*goapollo.Client, func()) {
+	dir, err := ioutil.TempDir("", "apollotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append([]goapollo.Option{
+		goapollo.WithHost(server.URL),
+		goapollo.WithCacheDir(dir),
+		goapollo.WithNamespaces("application"),
+	}, opts...)
+	c, err := goapollo.NewWithConfig(goapollo.Config{AppId: "SampleApp"}, opts...)
+	if err != nil {
+		t.Fatalf("创建客户端失败 -> %s", err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	return c, func() {
+		_ = c.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func waitEvent(t *testing.T, c *goapollo.Client) *goapollo.ChangeEvent {
+	select {
+	case event := <-c.WatchUpdate():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("未收到变更事件")
+	}
+	return nil
+}
+
+func TestServer_PublishAndDelete(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100", "name": "demo"})
+
+	c, cleanup := newClient(t, server)
+	defer cleanup()
+	if v, _ := c.GetValue("timeout"); v != "100" {
+		t.Fatalf("获取配置失败 -> %s", v)
+	}
+	waitEvent(t, c)
+
+	server.SetValue("SampleApp", "default", "application", "timeout", "200")
+	if change := waitEvent(t, c).Changes["timeout"]; change == nil || change.ChangeType != goapollo.EventModify || change.NewValue != "200" {
+		t.Fatalf("修改事件错误 -> %+v", change)
+	}
+
+	server.DeleteValue("SampleApp", "default", "application", "name")
+	if change := waitEvent(t, c).Changes["name"]; change == nil || change.ChangeType != goapollo.EventDelete {
+		t.Fatalf("删除事件错误 -> %+v", change)
+	}
+	if c.GetReleaseKey("application") != server.ReleaseKey("SampleApp", "default", "application") {
+		t.Fatalf("版本号不一致 -> %s", c.GetReleaseKey("application"))
+	}
+}
+
+func TestServer_GrayRelease(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+
+	gray, cleanup := newClient(t, server, goapollo.WithLabel("canary"))
+	defer cleanup()
+	normal, cleanup := newClient(t, server)
+	defer cleanup()
+	waitEvent(t, gray)
+	waitEvent(t, normal)
+
+	server.GrayRelease("SampleApp", "default", "application", apollotest.GrayRule{Labels: []string{"canary"}}, map[string]string{"timeout": "300"})
+	if change := waitEvent(t, gray).Changes["timeout"]; change == nil || change.NewValue != "300" {
+		t.Fatalf("灰度事件错误 -> %+v", change)
+	}
+	select {
+	case event := <-normal.WatchUpdate():
+		t.Fatalf("未命中灰度规则的客户端不应收到事件 -> %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	server.AbandonGray("SampleApp", "default", "application")
+	if change := waitEvent(t, gray).Changes["timeout"]; change == nil || change.NewValue != "100" {
+		t.Fatalf("放弃灰度事件错误 -> %+v", change)
+	}
+}
+
+func TestServer_ConfigFiles(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+
+	resp, err := http.Get(server.URL + "/configfiles/json/SampleApp/dev/application")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var values map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		t.Fatal(err)
+	}
+	if values["timeout"] != "100" {
+		t.Fatalf("集群不存在时应使用默认集群 -> %v", values)
+	}
+
+	resp, err = http.Get(server.URL + "/configs/SampleApp/default/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("命名空间不存在时应返回 404 -> %d", resp.StatusCode)
+	}
+}
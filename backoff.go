@@ -0,0 +1,128 @@
+package goapollo
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff 请求失败后的指数退避策略，成功后重新从 Initial 开始计算.
+type Backoff struct {
+	// Initial 第一次失败后的等待时间.
+	Initial time.Duration
+	// Max 最长等待时间.
+	Max time.Duration
+	// Multiplier 每次失败后等待时间的增长倍数.
+	Multiplier float64
+	// Jitter 随机抖动比例，取值 0 到 1，避免大量客户端同时重试.
+	Jitter float64
+	// Retries 配置拉取失败后的最大重试次数，长轮询通知会一直重试.
+	Retries int
+}
+
+// DefaultBackoff 默认退避策略，从 1 秒开始翻倍，最长 2 分钟.
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    time.Second,
+		Max:        2 * time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+		Retries:    3,
+	}
+}
+
+// delay 计算第 attempt 次失败后的等待时间，attempt 从 0 开始.
+func (b Backoff) delay(attempt int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	d := float64(b.Initial)
+	for i := 0; i < attempt; i++ {
+		if b.Multiplier > 1 {
+			d *= b.Multiplier
+		}
+		if b.Max > 0 && d >= float64(b.Max) {
+			d = float64(b.Max)
+			break
+		}
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		jitter := b.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d += d * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+// backoffState 按命名空间分别记录连续失败次数，一个命名空间恢复不会影响其他命名空间的退避.
+type backoffState struct {
+	mux      *sync.Mutex
+	policy   Backoff
+	attempts map[string]int
+	until    map[string]time.Time
+}
+
+func newBackoffState(policy Backoff) *backoffState {
+	return &backoffState{mux: &sync.Mutex{}, policy: policy, attempts: map[string]int{}, until: map[string]time.Time{}}
+}
+
+// next 记录 key 的一次失败并返回需要等待的时间.
+func (b *backoffState) next(key string) time.Duration {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	d := b.policy.delay(b.attempts[key])
+	b.attempts[key]++
+	b.until[key] = time.Now().Add(d)
+	return d
+}
+
+// reset 请求成功后重置 key 的失败次数.
+func (b *backoffState) reset(key string) {
+	b.mux.Lock()
+	delete(b.attempts, key)
+	delete(b.until, key)
+	b.mux.Unlock()
+}
+
+// waiting 判断 key 是否仍在退避等待中.
+func (b *backoffState) waiting(key string) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	until, ok := b.until[key]
+	return ok && time.Now().Before(until)
+}
+
+func (b *backoffState) retries() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.policy.Retries
+}
+
+func (b *backoffState) setPolicy(policy Backoff) {
+	b.mux.Lock()
+	b.policy = policy
+	b.attempts = map[string]int{}
+	b.until = map[string]time.Time{}
+	b.mux.Unlock()
+}
+
+// sleep 等待指定时间，ctx 结束时返回 false.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
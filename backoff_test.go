@@ -0,0 +1,94 @@
+package goapollo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range expected {
+		if delay := b.delay(i); delay != d {
+			t.Errorf("第 %d 次退避时间错误 -> %s", i, delay)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := b.delay(1); delay < time.Second || delay > 3*time.Second {
+			t.Fatalf("抖动超出范围 -> %s", delay)
+		}
+	}
+
+	state := newBackoffState(Backoff{Initial: time.Second, Multiplier: 2})
+	state.next("application")
+	state.next("application")
+	state.reset("application")
+	if delay := state.next("application"); delay != time.Second {
+		t.Errorf("重置后退避时间错误 -> %s", delay)
+	}
+}
+
+func TestNotificationRepo_Backoff(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := newNotificationRepo(newStaticServices(server.URL), "SampleApp", "default")
+	repo.backoff.setPolicy(Backoff{Initial: 50 * time.Millisecond, Max: time.Second, Multiplier: 2})
+	repo.AddNamespace("application")
+	repo.Watch()
+	time.Sleep(300 * time.Millisecond)
+	_ = repo.Close()
+
+	if n := atomic.LoadInt32(&requests); n < 2 || n > 5 {
+		t.Fatalf("失败后应按退避策略重试 -> %d", n)
+	}
+}
+
+func TestClient_RefreshBackoffPerNamespace(t *testing.T) {
+	var healthy, broken int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			atomic.AddInt32(&broken, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		atomic.AddInt32(&healthy, 1)
+		_, _ = w.Write([]byte(`{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"1"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "SampleApp", "default")
+	c.SetBackoff(Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2})
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), "")
+	c.AddNamespaceWithSerializerWithPath("broken", NewJsonSerializer(), "")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		c.refreshAll(context.Background())
+	}
+	// 定时拉取不重试，也不应等待退避时间
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("定时拉取不应等待退避时间 -> %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&healthy); n != 5 {
+		t.Fatalf("正常的命名空间应每次都拉取 -> %d", n)
+	}
+	// 正常命名空间拉取成功不应重置失败命名空间的退避状态
+	if n := atomic.LoadInt32(&broken); n != 1 {
+		t.Fatalf("失败的命名空间应在退避期间跳过 -> %d", n)
+	}
+	if !c.backoff.waiting("broken") || c.backoff.waiting("application") {
+		t.Fatal("退避状态错误")
+	}
+}
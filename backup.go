@@ -0,0 +1,144 @@
+package goapollo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// backupMagic 备份文件头，格式为 "#goapollo-backup v1 sha256=<摘要> size=<长度>\n"，之后是序列化后的内容.
+	// 没有文件头的备份文件按旧格式直接读取，便于手工修改的 properties、yaml 和 toml 备份文件不写文件头.
+	backupMagic   = "#goapollo-backup"
+	backupVersion = "v1"
+	// backupSuffix 上一个可用版本的备份文件后缀.
+	backupSuffix = ".bak"
+)
+
+// ErrCorruptBackup 备份文件已损坏.
+var ErrCorruptBackup = errors.New("备份文件已损坏")
+
+// encodeBackup 为序列化后的内容添加版本和校验头.
+func encodeBackup(payload []byte) []byte {
+	sum := sha256.Sum256(payload)
+	header := fmt.Sprintf("%s %s sha256=%s size=%d\n", backupMagic, backupVersion, hex.EncodeToString(sum[:]), len(payload))
+
+	var buf bytes.Buffer
+	buf.Grow(len(header) + len(payload))
+	buf.WriteString(header)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// editable 判断序列化器写入的备份文件是否便于手工修改，这类备份文件不写文件头，修改后不会因校验失败而被忽略.
+func editable(serializer Serializer) bool {
+	switch serializer.(type) {
+	case *PropertiesSerializer, *YamlSerializer, *TomlSerializer:
+		return true
+	}
+	return false
+}
+
+// stripBackup 不做校验直接去掉文件头，用于判断校验失败的备份文件是否被手工修改过.
+func stripBackup(body []byte) []byte {
+	if !bytes.HasPrefix(body, []byte(backupMagic+" ")) {
+		return body
+	}
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		return body[i+1:]
+	}
+	return nil
+}
+
+// decodeBackup 校验并去掉文件头，没有文件头时原样返回.
+func decodeBackup(body []byte) ([]byte, error) {
+	if !bytes.HasPrefix(body, []byte(backupMagic+" ")) {
+		return body, nil
+	}
+	i := bytes.IndexByte(body, '\n')
+	if i < 0 {
+		return nil, ErrCorruptBackup
+	}
+	fields := strings.Fields(string(body[:i]))
+	payload := body[i+1:]
+	if len(fields) != 4 || fields[1] != backupVersion {
+		return nil, fmt.Errorf("不支持的备份文件版本 -> %s", string(body[:i]))
+	}
+	sum := strings.TrimPrefix(fields[2], "sha256=")
+	size, err := strconv.Atoi(strings.TrimPrefix(fields[3], "size="))
+	if err != nil || size != len(payload) {
+		return nil, ErrCorruptBackup
+	}
+	actual := sha256.Sum256(payload)
+	if hex.EncodeToString(actual[:]) != sum {
+		return nil, ErrCorruptBackup
+	}
+	return payload, nil
+}
+
+// readBackup 读取并校验备份文件.
+func readBackup(path string) ([]byte, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBackup(body)
+}
+
+// writeBackup 原子地写入备份文件：先写入同目录下的临时文件并刷盘，再重命名为目标文件，checksum 为 true 时写入校验头.
+// 原文件校验通过时会保留为 .bak 文件，作为下次读取失败时的备用版本.
+func writeBackup(path string, payload []byte, checksum bool) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if checksum {
+		payload = encodeBackup(payload)
+	}
+	if _, err := tmp.Write(payload); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	if _, err := readBackup(path); err == nil {
+		if err := os.Rename(path, path+backupSuffix); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir 刷新目录项，确保重命名在崩溃后仍然生效，部分平台不支持对目录执行 fsync，忽略错误.
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		_ = f.Sync()
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,228 @@
+package goapollo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackup_WriteRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "application.json")
+
+	if err := writeBackup(path, []byte("v1"), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBackup(path, []byte("v2"), true); err != nil {
+		t.Fatal(err)
+	}
+	if body, err := readBackup(path); err != nil || string(body) != "v2" {
+		t.Fatalf("读取备份文件失败 -> %s - %v", body, err)
+	}
+	if body, err := readBackup(path + backupSuffix); err != nil || string(body) != "v1" {
+		t.Fatalf("上一个版本不正确 -> %s - %v", body, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0644 {
+		t.Fatalf("文件权限不正确 -> %v - %v", fi.Mode(), err)
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(files) != 2 {
+		t.Fatalf("临时文件未清理 -> %d", len(files))
+	}
+
+	// 损坏的文件不会覆盖上一个可用版本
+	body, _ := ioutil.ReadFile(path)
+	if err := ioutil.WriteFile(path, body[:len(body)-1], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBackup(path); err != ErrCorruptBackup {
+		t.Fatalf("未检测到文件损坏 -> %v", err)
+	}
+	if err := writeBackup(path, []byte("v3"), true); err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := readBackup(path + backupSuffix); string(body) != "v1" {
+		t.Fatalf("上一个可用版本被覆盖 -> %s", body)
+	}
+}
+
+func TestBackup_Legacy(t *testing.T) {
+	body, err := decodeBackup([]byte(`{"namespace_name":"application"}`))
+	if err != nil || string(body) != `{"namespace_name":"application"}` {
+		t.Fatalf("读取旧格式失败 -> %s - %v", body, err)
+	}
+}
+
+func TestNamespaceCache_LoadFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "application.json")
+
+	c := newNamespaceCache()
+	c.addSerializer("application", NewJsonSerializer())
+	c.saves.Store("application", path)
+	mustStore(t, c, result{NamespaceName: "application", Configurations: map[string]string{"key": "v1"}})
+	if err := c.dump("application"); err != nil {
+		t.Fatal(err)
+	}
+	mustStore(t, c, result{NamespaceName: "application", Configurations: map[string]string{"key": "v2"}})
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写入中途崩溃导致文件被截断
+	body, _ := ioutil.ReadFile(path)
+	if err := ioutil.WriteFile(path, body[:len(body)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c = newNamespaceCache()
+	c.addSerializer("application", NewJsonSerializer())
+	if _, err := c.load("application", path); err != nil {
+		t.Fatalf("加载备份失败 -> %s", err)
+	}
+	if v, _ := c.get("application", "key"); v != "v1" {
+		t.Fatalf("未使用上一个可用版本 -> %s", v)
+	}
+}
+
+func TestNamespaceCache_HandEdited(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, serializer := range map[string]Serializer{"json": NewJsonSerializer(), "yaml": NewYamlSerializer()} {
+		path := filepath.Join(dir, name)
+		c := newNamespaceCache()
+		c.addSerializer("application", serializer)
+		c.saves.Store("application", path)
+		for _, value := range []string{"v1", "v2"} {
+			mustStore(t, c, result{NamespaceName: "application", Configurations: map[string]string{"key": value}})
+			if err := c.dump("application"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// 手工修改备份文件
+		body, _ := ioutil.ReadFile(path)
+		if editable(serializer) == bytes.HasPrefix(body, []byte(backupMagic)) {
+			t.Fatalf("%s: 文件头不正确 -> %s", name, body)
+		}
+		if err := ioutil.WriteFile(path, bytes.Replace(body, []byte("v2"), []byte("v3"), 1), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		logger := &bufferLogger{mux: &sync.Mutex{}}
+		c = newNamespaceCache()
+		c.logger = logger
+		c.addSerializer("application", serializer)
+		if _, err := c.load("application", path); err != nil {
+			t.Fatalf("%s: 加载备份失败 -> %s", name, err)
+		}
+		v, _ := c.get("application", "key")
+		if editable(serializer) {
+			if v != "v3" {
+				t.Fatalf("%s: 手工修改未生效 -> %s", name, v)
+			}
+			continue
+		}
+		// 带有文件头的备份文件修改后校验失败，使用上一个可用版本并给出明确的提示
+		if v != "v1" || !strings.Contains(logger.buf.String(), "校验和不匹配") || !strings.Contains(logger.buf.String(), path) {
+			t.Fatalf("%s: 校验失败时应使用上一个可用版本并提示 -> %s - %s", name, v, logger.buf.String())
+		}
+	}
+}
+
+func TestClient_RestoreRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mux := &sync.Mutex{}
+	downloads, notified := 0, ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/notifications/") {
+			mux.Lock()
+			notified = r.URL.Query().Get("notifications")
+			mux.Unlock()
+			if strings.Contains(notified, `"notificationId":-1`) {
+				_, _ = fmt.Fprint(w, `[{"namespaceName":"application","notificationId":7}]`)
+				return
+			}
+			<-r.Context().Done()
+			return
+		}
+		if r.URL.Query().Get("releaseKey") == "r1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		mux.Lock()
+		downloads++
+		mux.Unlock()
+		_, _ = fmt.Fprint(w, `{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"r1"}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "SampleApp", "default")
+	c.SetCacheDir(dir)
+	c.AddNamespace("application")
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	<-c.WatchUpdate()
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		if id, _ := c.caches.notificationId("application"); id == 7 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("未收到通知")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = c.Close()
+
+	c = New(server.URL, "SampleApp", "default")
+	c.SetCacheDir(dir)
+	c.AddNamespace("application")
+	if c.GetReleaseKey("application") != "r1" {
+		t.Fatalf("未恢复版本号 -> %s", c.GetReleaseKey("application"))
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	defer c.Close()
+	select {
+	case event := <-c.WatchUpdate():
+		t.Fatalf("配置未变更时不应发送事件 -> %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if downloads != 1 {
+		t.Fatalf("重启后重复下载配置 -> %d", downloads)
+	}
+	if !strings.Contains(notified, `"notificationId":7`) {
+		t.Fatalf("未恢复通知 ID -> %s", notified)
+	}
+}
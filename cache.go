@@ -3,146 +3,199 @@ package goapollo
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
-	"path/filepath"
 	"sync"
 )
 
 type namespaceCache struct {
-	mux         *sync.RWMutex
-	caches      map[string]*sync.Map
-	serializer  *sync.Map
-	saves       *sync.Map
-	releaseRepo *sync.Map
+	mux           *sync.RWMutex
+	caches        map[string]*sync.Map
+	serializer    *sync.Map
+	saves         *sync.Map
+	releaseRepo   *sync.Map
+	notifications *sync.Map
+	contents      *sync.Map
+	logger        ILogger
 }
 
 func newNamespaceCache() *namespaceCache {
 
 	return &namespaceCache{
-		caches:      map[string]*sync.Map{},
-		mux:         &sync.RWMutex{},
-		serializer:  &sync.Map{},
-		saves:       &sync.Map{},
-		releaseRepo: &sync.Map{},
+		caches:        map[string]*sync.Map{},
+		mux:           &sync.RWMutex{},
+		serializer:    &sync.Map{},
+		saves:         &sync.Map{},
+		releaseRepo:   &sync.Map{},
+		notifications: &sync.Map{},
+		contents:      &sync.Map{},
 	}
 }
 
-func (c *namespaceCache) load(namespace, path string) error {
-	c.saves.Store(namespace, path)
+func (c *namespaceCache) log() ILogger {
+	return loggerOrDefault(c.logger)
+}
 
-	body, err := ioutil.ReadFile(path)
-	if err != nil {
-		logger.Printf("读取缓存文件失败 ->%s - %s", path, err)
-		return err
-	}
-	var config Configuration
-	var serializer Serializer
+// load 从本地备份加载命名空间并返回与当前配置的差异，备份文件损坏或无法解析时使用上一个可用版本.
+func (c *namespaceCache) load(namespace, path string) (*ChangeEvent, error) {
+	c.saves.Store(namespace, path)
 
 	serializer, ok := c.getSerializer(namespace)
-
 	if !ok {
-		return fmt.Errorf("未找到可用的序列化器->%s", namespace)
+		return nil, fmt.Errorf("未找到可用的序列化器->%s", namespace)
 	}
-	err = serializer.Deserialize(body, &config)
+	config, err := c.read(namespace, path, serializer)
 	if err != nil {
-		logger.Printf("反序列化对象失败 -> [namespace=%s] - [error=%s]", namespace, err)
-		return err
+		var bakErr error
+		if config, bakErr = c.read(namespace, path+backupSuffix, serializer); bakErr != nil {
+			return nil, err
+		}
+		c.log().Printf("使用上一个可用的备份文件 -> %s", path+backupSuffix)
 	}
 
-	m := &sync.Map{}
-
-	for k, v := range config.Configurations {
-		m.Store(k, v)
+	event, err := c.store(result{NamespaceName: namespace, Configurations: config.Configurations})
+	if err != nil {
+		return nil, err
+	}
+	if config.ReleaseKey != "" {
+		c.setReleaseKey(namespace, config.ReleaseKey)
+	}
+	if config.NotificationId > 0 {
+		c.setNotificationId(namespace, config.NotificationId)
 	}
-	c.mux.Lock()
-	c.caches[namespace] = m
-	c.mux.Unlock()
 
-	return nil
+	return event, nil
 }
 
-func (c *namespaceCache) save() error {
-	if len(c.caches) <= 0 {
-		return nil
+func (c *namespaceCache) read(namespace, path string, serializer Serializer) (*Configuration, error) {
+	body, err := readBackup(path)
+	if err == ErrCorruptBackup {
+		// 内容仍然可以解析说明文件头未删除就被手工修改过，修改不会生效
+		if raw, readErr := ioutil.ReadFile(path); readErr == nil && serializer.Deserialize(stripBackup(raw), &Configuration{}) == nil {
+			c.log().Printf("备份文件校验和不匹配，可能被手工修改但未删除文件头，本次修改不会生效，将使用上一个可用版本 -> %s", path)
+			return nil, err
+		}
 	}
-	c.mux.RLock()
-	for name, m := range c.caches {
-		config := &Configuration{NamespaceName: name, Configurations: make(map[string]string)}
+	if err != nil {
+		c.log().Printf("读取缓存文件失败 ->%s - %s", path, err)
+		return nil, err
+	}
+	var config Configuration
+	if err := serializer.Deserialize(body, &config); err != nil {
+		c.log().Printf("反序列化对象失败 -> [namespace=%s] - [error=%s]", namespace, err)
+		return nil, err
+	}
+	return &config, nil
+}
 
-		path, ok := c.getSave(name)
-		if !ok {
-			continue
-		}
-		m.Range(func(key, value interface{}) bool {
-			config.Configurations[key.(string)] = value.(string)
-			return true
-		})
-		serializer, ok := c.getSerializer(name)
+func (c *namespaceCache) save() error {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
 
-		if !ok {
-			serializer = NewJsonSerializer()
-		}
-		body, err := serializer.Serialize(config)
-		if err != nil {
-			logger.Printf("序列化对象失败 -> [namespace=%s] - [error=%s]", name, err)
-			return err
-		}
-		if err := ioutil.WriteFile(path, body, 0755); err != nil {
+	for name := range c.caches {
+		if err := c.write(name); err != nil {
 			return err
 		}
 	}
-	c.mux.RUnlock()
-
 	return nil
 }
 
 func (c *namespaceCache) dump(namespace string) error {
-	if len(c.caches) <= 0 {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if _, ok := c.caches[namespace]; !ok {
 		return nil
 	}
-	dir, ok := c.getSave(namespace)
+	return c.write(namespace)
+}
+
+// write 序列化命名空间并原子地写入备份文件，调用方需持有读锁.
+func (c *namespaceCache) write(namespace string) error {
+	path, ok := c.getSave(namespace)
 	if !ok {
-		logger.Printf("备份目录不存在 -> %s", namespace)
+		c.log().Printf("备份目录不存在 -> %s", namespace)
 		return nil
 	}
+	serializer, ok := c.getSerializer(namespace)
 
-	if _, err := os.Stat(filepath.Dir(dir)); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			logger.Printf("创建目录失败 -> %s - %s", dir, err)
-			return err
-		}
+	if !ok {
+		serializer = NewJsonSerializer()
+	}
+	body, err := c.marshal(namespace, serializer)
+	if err != nil {
+		return err
+	}
+	if err := writeBackup(path, body, !editable(serializer)); err != nil {
+		c.log().Printf("保存文件失败->[namespace=%s] - [error=%s]", namespace, err)
+		return err
 	}
+	c.log().Printf("备份文件已保存 -> %s - %s - %+v", namespace, path, serializer)
+	return nil
+}
+
+// export 使用指定的序列化器序列化命名空间.
+func (c *namespaceCache) export(namespace string, serializer Serializer) ([]byte, error) {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
 
-	if m, ok := c.caches[namespace]; ok {
-		config := &Configuration{NamespaceName: namespace, Configurations: make(map[string]string)}
+	if _, ok := c.caches[namespace]; !ok {
+		return nil, fmt.Errorf("命名空间不存在 -> %s", namespace)
+	}
+	return c.marshal(namespace, serializer)
+}
 
+// marshal 序列化命名空间的备份内容，调用方需持有读锁.
+func (c *namespaceCache) marshal(namespace string, serializer Serializer) ([]byte, error) {
+	body, err := serializer.Serialize(c.snapshot(namespace))
+	if err != nil {
+		c.log().Printf("序列化对象失败 -> [namespace=%s] - [error=%s]", namespace, err)
+		return nil, err
+	}
+	return body, nil
+}
+
+// snapshot 获取命名空间的原始配置，yaml 和 json 格式保存原始文档而不是展开后的键值对，调用方需持有读锁.
+func (c *namespaceCache) snapshot(namespace string) *Configuration {
+	config := &Configuration{NamespaceName: namespace, Configurations: make(map[string]string)}
+	config.ReleaseKey = c.releaseKey(namespace)
+	if id, ok := c.notificationId(namespace); ok {
+		config.NotificationId = id
+	}
+	if content, ok := c.contents.Load(namespace); ok {
+		config.Configurations[contentKey] = content.(string)
+		return config
+	}
+	if m, ok := c.caches[namespace]; ok {
 		m.Range(func(key, value interface{}) bool {
 			config.Configurations[key.(string)] = value.(string)
 			return true
 		})
-		serializer, ok := c.getSerializer(namespace)
+	}
+	return config
+}
 
-		if !ok {
-			serializer = NewJsonSerializer()
-		}
-		body, err := serializer.Serialize(config)
-		if err != nil {
-			logger.Printf("序列化对象失败 -> [namespace=%s] - [error=%s]", namespace, err)
-			return err
-		}
-		if err := ioutil.WriteFile(dir, body, 0755); err != nil {
-			logger.Printf("保存文件失败->[namespace=%s] - [error=%s]", namespace, err)
-			return err
-		}
-		logger.Printf("备份文件已保存 -> %s - %s - %+v", namespace, dir, serializer)
+// values 获取命名空间对外提供的键值对，yaml 和 json 格式的文档会被展开.
+func (c *namespaceCache) values(result result) (map[string]string, error) {
+	format := NamespaceFormat(result.NamespaceName)
+	content, ok := result.Configurations[contentKey]
+	if !ok || !format.structured() {
+		return result.Configurations, nil
 	}
-	return nil
+	values, err := flatten(format, content)
+	if err != nil {
+		return nil, err
+	}
+	c.contents.Store(result.NamespaceName, content)
+	return values, nil
 }
 
-func (c *namespaceCache) store(result result) *ChangeEvent {
+// store 保存命名空间的配置并返回变更，文档无法解析时返回错误且不修改当前配置.
+func (c *namespaceCache) store(result result) (*ChangeEvent, error) {
 	event := ChangeEvent{Namespace: result.NamespaceName, Changes: make(map[string]*Change)}
+	configurations, err := c.values(result)
+	if err != nil {
+		c.log().Printf("解析配置文档失败 -> [namespace=%s] - [error=%s]", result.NamespaceName, err)
+		return nil, fmt.Errorf("解析配置文档失败 -> %s - %s", result.NamespaceName, err)
+	}
 	c.mux.Lock()
 	defer c.mux.Unlock()
 	m, ok := c.caches[result.NamespaceName]
@@ -158,7 +211,7 @@ func (c *namespaceCache) store(result result) *ChangeEvent {
 		})
 	}
 
-	for k, v := range result.Configurations {
+	for k, v := range configurations {
 		m.Store(k, v)
 		if change, ok := event.Changes[k]; ok {
 			if v == change.OldValue {
@@ -173,13 +226,21 @@ func (c *namespaceCache) store(result result) *ChangeEvent {
 	}
 
 	for k := range event.Changes {
-		if _, ok := result.Configurations[k]; !ok {
+		if _, ok := configurations[k]; !ok {
 			change := event.Changes[k]
 			change.ChangeType = EventDelete
+			m.Delete(k)
 		}
 	}
 
-	return &event
+	return &event, nil
+}
+
+func (c *namespaceCache) has(namespace string) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	_, ok := c.caches[namespace]
+	return ok
 }
 
 func (c *namespaceCache) get(namespace string, key string) (string, bool) {
@@ -193,6 +254,14 @@ func (c *namespaceCache) get(namespace string, key string) (string, bool) {
 	return "", false
 }
 
+// content 获取命名空间的原始文档.
+func (c *namespaceCache) content(namespace string) (string, bool) {
+	if content, ok := c.contents.Load(namespace); ok {
+		return content.(string), true
+	}
+	return c.get(namespace, contentKey)
+}
+
 func (c *namespaceCache) keys(namespace string) []string {
 	var keys []string
 	c.mux.RLock()
@@ -207,6 +276,30 @@ func (c *namespaceCache) keys(namespace string) []string {
 	return keys
 }
 
+// releaseKey 获取命名空间当前配置的版本号.
+func (c *namespaceCache) releaseKey(namespace string) string {
+	if releaseKey, ok := c.releaseRepo.Load(namespace); ok {
+		return releaseKey.(string)
+	}
+	return ""
+}
+
+func (c *namespaceCache) setReleaseKey(namespace, releaseKey string) {
+	c.releaseRepo.Store(namespace, releaseKey)
+}
+
+// notificationId 获取命名空间最后一次收到的通知 ID.
+func (c *namespaceCache) notificationId(namespace string) (int, bool) {
+	if id, ok := c.notifications.Load(namespace); ok {
+		return id.(int), true
+	}
+	return defaultNotificationId, false
+}
+
+func (c *namespaceCache) setNotificationId(namespace string, id int) {
+	c.notifications.Store(namespace, id)
+}
+
 func (c *namespaceCache) addSerializer(namespace string, serializer Serializer) {
 	c.serializer.Store(namespace, serializer)
 }
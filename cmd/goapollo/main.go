@@ -0,0 +1,360 @@
+// Command goapollo 是 Apollo 配置中心的命令行工具，用于在终端中查看客户端实际获取到的配置.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/lifei6671/goapollo"
+)
+
+const usage = `goapollo 是 Apollo 配置中心的命令行工具.
+
+用法:
+
+	goapollo <命令> [参数] <命名空间> ...
+
+命令:
+
+	get <namespace> <key>   获取配置项的值
+	keys <namespace>        列出命名空间的所有键
+	watch <namespace>...    监听命名空间的变更，每个事件输出一行 JSON
+	dump <namespace>        按备份文件的格式输出命名空间
+	diff <namespace>        比较两个集群或本地备份文件与配置服务的差异
+
+参数需要放在命名空间之前，未设置的参数使用与默认客户端相同的配置文件和环境变量，
+例如 APOLLO_CONFIG_SERVICE、APOLLO_META、APOLLO_APP_ID、APOLLO_CLUSTER、APOLLO_LABEL、APOLLO_ACCESS_KEY_SECRET.
+执行 goapollo <命令> -h 查看命令的参数.
+`
+
+// serializers dump 命令支持的序列化器.
+var serializers = map[string]func() goapollo.Serializer{
+	"json":       func() goapollo.Serializer { return goapollo.NewJsonSerializer() },
+	"gob":        func() goapollo.Serializer { return goapollo.NewGobSerializer() },
+	"properties": func() goapollo.Serializer { return goapollo.NewPropertiesSerializer() },
+	"yaml":       func() goapollo.Serializer { return goapollo.NewYamlSerializer() },
+	"toml":       func() goapollo.Serializer { return goapollo.NewTomlSerializer() },
+}
+
+type options struct {
+	config     string
+	host       string
+	meta       string
+	appId      string
+	cluster    string
+	label      string
+	secret     string
+	ip         string
+	verbose    bool
+	serializer string
+	output     string
+	to         string
+	backup     string
+	format     string
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+
+	var opts options
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.config, "config", "", "配置文件，默认使用 APOLLO_CONFIG_FILE 或当前目录下的 apollo.yaml")
+	fs.StringVar(&opts.host, "host", "", "配置服务地址")
+	fs.StringVar(&opts.meta, "meta", "", "Meta Server 地址，多个用逗号分隔")
+	fs.StringVar(&opts.appId, "app", "", "AppId")
+	fs.StringVar(&opts.cluster, "cluster", "", "集群")
+	fs.StringVar(&opts.label, "label", "", "客户端标签，多个用逗号分隔")
+	fs.StringVar(&opts.secret, "secret", "", "访问密钥")
+	fs.StringVar(&opts.ip, "ip", "", "客户端 IP，用于匹配灰度规则，默认自动选择本机地址")
+	fs.BoolVar(&opts.verbose, "v", false, "输出客户端日志")
+	switch command {
+	case "dump":
+		fs.StringVar(&opts.serializer, "serializer", "json", "序列化器，支持 json、gob、properties、yaml 和 toml")
+		fs.StringVar(&opts.output, "o", "", "输出文件，默认输出到标准输出")
+	case "diff":
+		fs.StringVar(&opts.to, "to", "", "比较的目标集群")
+		fs.StringVar(&opts.backup, "backup", "", "比较的本地备份文件，与 -to 二选一")
+		fs.StringVar(&opts.serializer, "serializer", "json", "备份文件的序列化器，支持 json、gob、properties、yaml 和 toml")
+		fs.StringVar(&opts.format, "format", "text", "输出格式，支持 text、json 和 unified")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	args = fs.Args()
+
+	var err error
+	switch command {
+	case "get":
+		if len(args) != 2 {
+			return usageError(stderr, "用法: goapollo get [参数] <namespace> <key>")
+		}
+		err = get(ctx, &opts, args[0], args[1], stdout)
+	case "keys":
+		if len(args) != 1 {
+			return usageError(stderr, "用法: goapollo keys [参数] <namespace>")
+		}
+		err = keys(ctx, &opts, args[0], stdout)
+	case "watch":
+		if len(args) == 0 {
+			return usageError(stderr, "用法: goapollo watch [参数] <namespace>...")
+		}
+		err = watch(ctx, &opts, args, stdout)
+	case "dump":
+		if len(args) != 1 {
+			return usageError(stderr, "用法: goapollo dump [参数] <namespace>")
+		}
+		err = dump(ctx, &opts, args[0], stdout)
+	case "diff":
+		if len(args) != 1 || (opts.to == "") == (opts.backup == "") {
+			return usageError(stderr, "用法: goapollo diff [参数] -to <cluster> | -backup <file> <namespace>")
+		}
+		err = diff(&opts, args[0], stdout)
+	default:
+		return usageError(stderr, "未知的命令 -> "+command)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func usageError(stderr io.Writer, message string) int {
+	_, _ = fmt.Fprintln(stderr, message)
+	return 2
+}
+
+// loadConfig 加载配置文件和环境变量，并使用命令行参数覆盖.
+func loadConfig(opts *options) (goapollo.Config, error) {
+	conf, err := goapollo.LoadConfig(opts.config)
+	if err != nil {
+		return conf, err
+	}
+	if opts.host != "" {
+		conf.Host, conf.MetaServer = opts.host, ""
+	}
+	if opts.meta != "" {
+		conf.MetaServer, conf.Host = opts.meta, ""
+	}
+	if opts.appId != "" {
+		conf.AppId = opts.appId
+	}
+	if opts.cluster != "" {
+		conf.Cluster = opts.cluster
+	}
+	if opts.label != "" {
+		conf.Label = opts.label
+	}
+	if opts.secret != "" {
+		conf.Secret = opts.secret
+	}
+	if opts.ip != "" {
+		conf.IP = opts.ip
+	}
+	if !opts.verbose {
+		conf.Logger = log.New(ioutil.Discard, "", 0)
+	}
+	return conf, nil
+}
+
+// start 创建客户端并从配置服务拉取命名空间，返回的函数用于关闭客户端并清理临时备份目录.
+func start(ctx context.Context, opts *options, namespaces ...string) (*goapollo.Client, func(), error) {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf.Namespaces = namespaces
+	conf.ReadyPolicy = goapollo.ReadyPolicyRemote
+
+	// 使用临时目录保存备份，避免读取到本地旧的备份文件
+	dir := ""
+	if !conf.Offline {
+		if dir, err = ioutil.TempDir("", "goapollo"); err != nil {
+			return nil, nil, err
+		}
+		conf.CacheDir = dir
+	}
+	cleanup := func() {
+		if dir != "" {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	c, err := goapollo.NewWithConfig(conf)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if err := c.Run(ctx); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return c, func() {
+		_ = c.Close()
+		cleanup()
+	}, nil
+}
+
+func get(ctx context.Context, opts *options, namespace, key string, w io.Writer) error {
+	c, closer, err := start(ctx, opts, namespace)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	val, ok := c.GetValueWithNamespace(namespace, key)
+	if !ok {
+		return fmt.Errorf("配置项不存在 -> %s - %s", namespace, key)
+	}
+	_, err = fmt.Fprintln(w, val)
+	return err
+}
+
+func keys(ctx context.Context, opts *options, namespace string, w io.Writer) error {
+	c, closer, err := start(ctx, opts, namespace)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	names := c.AllKeys(namespace)
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dump(ctx context.Context, opts *options, namespace string, w io.Writer) error {
+	newSerializer, ok := serializers[opts.serializer]
+	if !ok {
+		return fmt.Errorf("未知的序列化器 -> %s", opts.serializer)
+	}
+	c, closer, err := start(ctx, opts, namespace)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if opts.output == "" {
+		return c.Dump(namespace, newSerializer(), w)
+	}
+	f, err := os.OpenFile(opts.output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := c.Dump(namespace, newSerializer(), f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type changeLine struct {
+	Namespace string                 `json:"namespace"`
+	Changes   map[string]*changeItem `json:"changes"`
+}
+
+type changeItem struct {
+	Type     string `json:"type"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+// watch 输出命名空间的变更事件直到 ctx 结束，第一个事件包含命名空间当前的全部配置.
+func watch(ctx context.Context, opts *options, namespaces []string, w io.Writer) error {
+	c, closer, err := start(ctx, opts, namespaces...)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for {
+		select {
+		case event, ok := <-c.WatchUpdate():
+			if !ok {
+				return nil
+			}
+			line := changeLine{Namespace: event.Namespace, Changes: make(map[string]*changeItem, len(event.Changes))}
+			for key, change := range event.Changes {
+				line.Changes[key] = &changeItem{Type: change.ChangeType.String(), OldValue: change.OldValue, NewValue: change.NewValue}
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// diff 输出客户端所在集群与目标集群或本地备份文件的差异，不会启动变更监听.
+func diff(opts *options, namespace string, w io.Writer) error {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+	c, err := goapollo.NewWithConfig(conf)
+	if err != nil {
+		return err
+	}
+	var report *goapollo.DiffReport
+	if opts.backup != "" {
+		newSerializer, ok := serializers[opts.serializer]
+		if !ok {
+			return fmt.Errorf("未知的序列化器 -> %s", opts.serializer)
+		}
+		report, err = c.DiffBackup(namespace, opts.backup, newSerializer())
+	} else {
+		cluster := conf.Cluster
+		if cluster == "" {
+			cluster = "default"
+		}
+		report, err = c.DiffClusters(namespace, cluster, opts.to)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch opts.format {
+	case "text":
+		_, err = io.WriteString(w, report.Text())
+	case "unified":
+		_, err = io.WriteString(w, report.Unified())
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(report)
+	default:
+		err = fmt.Errorf("未知的输出格式 -> %s", opts.format)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lifei6671/goapollo/apollotest"
+)
+
+type syncBuffer struct {
+	mux *sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
+
+func TestRun(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100", "name": "demo"})
+
+	exec := func(args ...string) (string, int) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{args[0], "-host", server.URL, "-app", "SampleApp"}, args[1:]...)
+		code := run(context.Background(), args, &stdout, &stderr)
+		return stdout.String() + stderr.String(), code
+	}
+
+	if out, code := exec("get", "application", "timeout"); code != 0 || out != "100\n" {
+		t.Fatalf("get 命令错误 -> %d - %s", code, out)
+	}
+	if out, code := exec("get", "application", "unknown"); code != 1 {
+		t.Fatalf("配置项不存在时应返回 1 -> %d - %s", code, out)
+	}
+	if out, code := exec("keys", "application"); code != 0 || out != "name\ntimeout\n" {
+		t.Fatalf("keys 命令错误 -> %d - %s", code, out)
+	}
+	if out, code := exec("dump", "application"); code != 0 || !strings.HasPrefix(out, "#goapollo-backup v1 ") || !strings.Contains(out, `"timeout":"100"`) {
+		t.Fatalf("dump 命令错误 -> %d - %s", code, out)
+	}
+	if out, code := exec("dump", "-serializer", "xml", "application"); code != 1 {
+		t.Fatalf("未知的序列化器应返回 1 -> %d - %s", code, out)
+	}
+	if _, code := exec("unknown"); code != 2 {
+		t.Fatalf("未知的命令应返回 2 -> %d", code)
+	}
+}
+
+func TestRun_Watch(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout := &syncBuffer{mux: &sync.Mutex{}}
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"watch", "-host", server.URL, "-app", "SampleApp", "application"}, stdout, stdout)
+	}()
+
+	waitLines := func(n int) []string {
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) >= n && lines[0] != "" {
+				return lines
+			}
+		}
+		t.Fatalf("未输出变更事件 -> %s", stdout.String())
+		return nil
+	}
+	waitLines(1)
+	server.SetValue("SampleApp", "default", "application", "timeout", "200")
+	lines := waitLines(2)
+	expected := `{"namespace":"application","changes":{"timeout":{"type":"MODIFY","old_value":"100","new_value":"200"}}}`
+	if lines[1] != expected {
+		t.Fatalf("变更事件格式错误 -> %s", lines[1])
+	}
+
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("watch 命令退出码错误 -> %d", code)
+	}
+}
+
+func TestRun_Diff(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+	server.Publish("SampleApp", "SHAJQ", "application", map[string]string{"timeout": "200"})
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"diff", "-host", server.URL, "-app", "SampleApp", "-to", "SHAJQ", "-format", "unified", "application"}, &stdout, &stderr)
+	expected := "--- default\n+++ SHAJQ\n@@ -1,1 +1,1 @@\n-timeout=100\n+timeout=200\n"
+	if code != 0 || stdout.String() != expected {
+		t.Fatalf("diff 命令错误 -> %d - %s%s", code, stdout.String(), stderr.String())
+	}
+
+	if code := run(context.Background(), []string{"diff", "-host", server.URL, "-app", "SampleApp", "application"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("未指定 -to 或 -backup 时应返回 2 -> %d", code)
+	}
+}
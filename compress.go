@@ -0,0 +1,64 @@
+package goapollo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+)
+
+// gzipMagic gzip 数据开头的两个字节.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// CompressedSerializer 使用 gzip 压缩其他序列化器的输出，读取时根据文件头判断是否压缩，开启压缩前的备份文件仍然可以读取.
+type CompressedSerializer struct {
+	serializer Serializer
+	level      int
+}
+
+// NewCompressedSerializer 创建使用默认压缩级别的压缩序列化器，serializer 为实际使用的序列化器.
+func NewCompressedSerializer(serializer Serializer) *CompressedSerializer {
+	return &CompressedSerializer{serializer: serializer, level: gzip.DefaultCompression}
+}
+
+// NewCompressedSerializerLevel 创建指定压缩级别的压缩序列化器，level 的取值与 compress/gzip 相同.
+func NewCompressedSerializerLevel(serializer Serializer, level int) (*CompressedSerializer, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return &CompressedSerializer{serializer: serializer, level: level}, nil
+}
+
+func (s *CompressedSerializer) Serialize(v *Configuration) ([]byte, error) {
+	body, err := s.serializer.Serialize(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, s.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *CompressedSerializer) Deserialize(body []byte, target *Configuration) error {
+	if !bytes.HasPrefix(body, gzipMagic) {
+		return s.serializer.Deserialize(body, target)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return s.serializer.Deserialize(plaintext, target)
+}
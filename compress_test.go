@@ -0,0 +1,95 @@
+package goapollo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompressedSerializer(t *testing.T) {
+	config := &Configuration{
+		NamespaceName:  "application.json",
+		ReleaseKey:     "r1",
+		Configurations: map[string]string{"content": strings.Repeat(`{"name":"goapollo"},`, 1000)},
+	}
+	for name, inner := range map[string]Serializer{"json": NewJsonSerializer(), "gob": NewGobSerializer(), "yaml": NewYamlSerializer()} {
+		plain, err := inner.Serialize(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serializer := NewCompressedSerializer(inner)
+		body, err := serializer.Serialize(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(body, gzipMagic) || len(body) >= len(plain) {
+			t.Fatalf("%s: 序列化结果未压缩 -> %d - %d", name, len(body), len(plain))
+		}
+
+		// 开启压缩前写入的备份文件仍然可以读取
+		for _, data := range [][]byte{body, plain} {
+			var target Configuration
+			if err := serializer.Deserialize(data, &target); err != nil {
+				t.Fatalf("%s: 反序列化失败 -> %s", name, err)
+			}
+			if !reflect.DeepEqual(&target, config) {
+				t.Fatalf("%s: 反序列化结果不正确 -> %s", name, target.ReleaseKey)
+			}
+		}
+	}
+
+	if err := NewCompressedSerializer(NewJsonSerializer()).Deserialize(append([]byte(nil), gzipMagic...), &Configuration{}); err == nil {
+		t.Fatal("损坏的压缩数据应返回错误")
+	}
+	if _, err := NewCompressedSerializerLevel(NewJsonSerializer(), 100); err == nil {
+		t.Fatal("无效的压缩级别应返回错误")
+	}
+	if _, err := NewCompressedSerializerLevel(NewJsonSerializer(), gzip.BestSpeed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_CompressedBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 先使用未压缩的序列化器写入备份
+	conf := Config{Host: "http://127.0.0.1:1", AppId: "app", CacheDir: dir}
+	c, err := NewWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddNamespace("application")
+	mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{"a": "1"}, ReleaseKey: "r1"})
+	c.caches.setReleaseKey("application", "r1")
+	if err := c.caches.dump("application"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = NewWithConfig(conf, WithSerializer(NewCompressedSerializer(NewJsonSerializer())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddNamespace("application")
+	if val, _ := c.GetValue("a"); val != "1" {
+		t.Fatalf("开启压缩后读取旧的备份失败 -> %s", val)
+	}
+	if err := c.caches.dump("application"); err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBackup(filepath.Join(dir, "app", "application"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(body, gzipMagic) {
+		t.Fatal("备份文件未压缩")
+	}
+}
@@ -3,12 +3,19 @@ package goapollo
 import (
 	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type Configuration struct {
 	NamespaceName  string            `json:"namespace_name"`
 	Configurations map[string]string `json:"configurations"`
 	ReleaseKey     string            `json:"release_key"`
+	NotificationId int               `json:"notification_id,omitempty"`
 }
 
 func (c *Configuration) String() string {
@@ -22,12 +29,113 @@ func (c *Configuration) String() string {
 	return string(body)
 }
 
+// Config 客户端配置，通过 NewWithConfig 创建客户端时使用.
 type Config struct {
-	Host       string   `json:"host"`
+	// Host 配置服务地址，与 MetaServer 二选一.
+	Host string `json:"host"`
+	// MetaServer Meta Server 地址，多个可用逗号分隔，与 Host 二选一.
+	MetaServer string   `json:"meta_server,omitempty"`
 	AppId      string   `json:"app_id"`
 	Cluster    string   `json:"cluster"`
 	Namespaces []string `json:"namespaces"`
-	IP         string   `json:"ip,omitempty"`
+	// IP 灰度发布规则匹配的客户端 IP，为空时自动选择本机的非回环地址.
+	IP string `json:"ip,omitempty"`
+	// IPPreference 自动选择 IP 时的偏好，可以是网卡名称或 CIDR，按顺序匹配.
+	IPPreference []string `json:"ip_preference,omitempty"`
+	// Label 灰度发布规则匹配的客户端标签，多个标签用逗号分隔.
+	Label string `json:"label,omitempty"`
+	// Secret 访问密钥.
+	Secret string `json:"secret,omitempty"`
+	// CacheDir 本地备份目录，默认为系统临时目录.
+	CacheDir string `json:"cache_dir,omitempty"`
+	// Timeout 获取配置请求的超时时间，默认 30 秒.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// LongPollTimeout 长轮询通知请求的超时时间，默认 90 秒，需要大于服务端的挂起时间.
+	LongPollTimeout time.Duration `json:"long_poll_timeout,omitempty"`
+	// EventBufferSize 变更事件通道的缓冲大小，默认 100.
+	EventBufferSize int `json:"event_buffer_size,omitempty"`
+	// RefreshInterval 定时全量拉取的间隔，默认 5 分钟，小于 0 时关闭.
+	RefreshInterval time.Duration `json:"refresh_interval,omitempty"`
+	ReadyPolicy     ReadyPolicy   `json:"ready_policy,omitempty"`
+	// ReadyTimeout 初始加载的超时时间，默认 30 秒.
+	ReadyTimeout time.Duration `json:"ready_timeout,omitempty"`
+	DeliveryMode DeliveryMode  `json:"delivery_mode,omitempty"`
+	// Offline 离线模式，只从 CacheDir 下的备份文件加载配置，不连接配置服务，此时可以不设置 Host 和 MetaServer.
+	Offline bool `json:"offline,omitempty"`
+	// WatchInterval 离线模式下检查备份文件是否修改的间隔，文件修改后发送变更事件，0 表示不检查.
+	WatchInterval time.Duration `json:"watch_interval,omitempty"`
+
+	// HTTPClient 自定义 HTTP 客户端，不能与 Transport 或 Timeout 同时设置.
+	HTTPClient *http.Client `json:"-"`
+	// Transport 自定义 HTTP 传输层.
+	Transport http.RoundTripper `json:"-"`
+	// Logger 客户端使用的日志接口，未设置时使用全局日志.
+	Logger ILogger `json:"-"`
+	// Decryptor 解密 ENC(...) 格式配置值的解密器.
+	Decryptor Decryptor `json:"-"`
+	// Serializer AddNamespace 和 Namespaces 中的命名空间使用的序列化器，默认为 json 序列化器.
+	Serializer Serializer `json:"-"`
+}
+
+// Validate 校验配置是否完整以及各配置项之间是否冲突.
+func (c *Config) Validate() error {
+	if c.AppId == "" {
+		return errors.New("AppId 不能为空")
+	}
+	if c.Host == "" && c.MetaServer == "" && !c.Offline {
+		return errors.New("Host 和 MetaServer 不能同时为空")
+	}
+	if c.Host != "" && c.MetaServer != "" {
+		return errors.New("Host 和 MetaServer 不能同时设置")
+	}
+	if c.Host != "" {
+		if err := validateURL(c.Host); err != nil {
+			return fmt.Errorf("Host 无效 -> %s", err)
+		}
+	}
+	for _, meta := range strings.Split(c.MetaServer, ",") {
+		if meta = strings.TrimSpace(meta); meta != "" {
+			if err := validateURL(meta); err != nil {
+				return fmt.Errorf("MetaServer 无效 -> %s", err)
+			}
+		}
+	}
+	if c.HTTPClient != nil && c.Transport != nil {
+		return errors.New("HTTPClient 和 Transport 不能同时设置")
+	}
+	if c.HTTPClient != nil && c.Timeout != 0 {
+		return errors.New("HTTPClient 和 Timeout 不能同时设置，请直接设置 HTTPClient.Timeout")
+	}
+	if c.Timeout < 0 || c.LongPollTimeout < 0 || c.ReadyTimeout < 0 {
+		return errors.New("超时时间不能小于 0")
+	}
+	if c.WatchInterval < 0 {
+		return errors.New("WatchInterval 不能小于 0")
+	}
+	if err := validateIPPreferences(c.IPPreference); err != nil {
+		return err
+	}
+	if c.EventBufferSize < 0 {
+		return errors.New("EventBufferSize 不能小于 0")
+	}
+	if c.ReadyPolicy < ReadyPolicyCache || c.ReadyPolicy > ReadyPolicyNone {
+		return fmt.Errorf("未知的 ReadyPolicy -> %d", c.ReadyPolicy)
+	}
+	if c.DeliveryMode < DeliveryDrop || c.DeliveryMode > DeliveryCoalesce {
+		return fmt.Errorf("未知的 DeliveryMode -> %d", c.DeliveryMode)
+	}
+	return nil
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("地址必须以 http:// 或 https:// 开头 -> %s", s)
+	}
+	return nil
 }
 
 func init() {
@@ -0,0 +1,65 @@
+package goapollo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	c, err := NewWithConfig(Config{AppId: "SampleApp"},
+		WithHost("http://localhost:8080"),
+		WithNamespaces("application", "wechat"),
+		WithTimeout(5*time.Second),
+		WithLongPollTimeout(70*time.Second),
+		WithCacheDir("/data/apollo"),
+		WithClientIP("10.0.0.1"),
+		WithLabel("canary"),
+		WithAccessKeySecret("secret"),
+		WithEventBufferSize(10),
+	)
+	if err != nil {
+		t.Fatalf("创建客户端失败 -> %s", err)
+	}
+	if c.cluster != "default" || c.cacheDir != "/data/apollo" || c.ip != "10.0.0.1" || c.label != "canary" || c.secret != "secret" {
+		t.Errorf("客户端配置错误 -> %+v", c)
+	}
+	if c.client.Timeout != 5*time.Second || c.notification.(*notificationRepo).client.Timeout != 70*time.Second {
+		t.Errorf("超时时间错误")
+	}
+	if cap(c.eventCh) != 10 || len(c.getNamespaces()) != 2 {
+		t.Errorf("事件缓冲或命名空间错误")
+	}
+
+	custom := &http.Client{Timeout: time.Second}
+	c, err = NewWithConfig(Config{MetaServer: "http://meta1:8080,http://meta2:8080", AppId: "SampleApp", HTTPClient: custom})
+	if err != nil {
+		t.Fatalf("创建客户端失败 -> %s", err)
+	}
+	if c.client != custom || c.notification.(*notificationRepo).client.Timeout != defaultLongPollTimeout {
+		t.Errorf("自定义 HTTP 客户端错误")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cases := map[string]Config{
+		"缺少 AppId":               {Host: "http://localhost"},
+		"缺少地址":                   {AppId: "SampleApp"},
+		"同时设置 Host 和 MetaServer": {Host: "http://localhost", MetaServer: "http://meta", AppId: "SampleApp"},
+		"无效的地址":                  {Host: "localhost:8080", AppId: "SampleApp"},
+		"同时设置 HTTPClient 和 Transport": {Host: "http://localhost", AppId: "SampleApp",
+			HTTPClient: &http.Client{}, Transport: &http.Transport{}},
+		"同时设置 HTTPClient 和 Timeout": {Host: "http://localhost", AppId: "SampleApp",
+			HTTPClient: &http.Client{}, Timeout: time.Second},
+		"负数的缓冲大小": {Host: "http://localhost", AppId: "SampleApp", EventBufferSize: -1},
+		"未知的投递方式": {Host: "http://localhost", AppId: "SampleApp", DeliveryMode: 10},
+	}
+	for name, conf := range cases {
+		if _, err := NewWithConfig(conf); err == nil {
+			t.Errorf("%s 应返回校验错误", name)
+		}
+	}
+	if _, err := NewWithConfig(Config{Host: "http://localhost", AppId: "SampleApp"}, WithEventBufferSize(-1)); err == nil {
+		t.Errorf("无效的选项应返回错误")
+	}
+}
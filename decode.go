@@ -0,0 +1,245 @@
+package goapollo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const tagName = "apollo"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Unmarshal 将指定命名空间的配置解析到结构体中，v 必须是结构体指针.
+//
+// 字段通过 apollo 标签指定键名和默认值，例如 `apollo:"timeout,default=3s"`，未设置标签时使用字段名，
+// 标签为 "-" 时忽略该字段. 嵌套结构体的键名作为前缀，使用点号连接，例如 db.host.
+// 切片和字典使用 GetStringSlice 和 GetStringMap 的规则解析，切片也可以来自 yaml 和 json 中展开的 key[0]、key[1].
+func (c *Client) Unmarshal(namespace string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("解析目标必须是非空的结构体指针")
+	}
+	return c.decodeStruct(namespace, "", rv.Elem())
+}
+
+func (c *Client) decodeStruct(namespace, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		key, defaultValue, hasDefault := parseTag(field)
+		if key == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if isNestedStruct(field.Type) {
+			nestedPrefix := prefix + key + "."
+			if field.Anonymous && field.Tag.Get(tagName) == "" {
+				nestedPrefix = prefix
+			}
+			if field.Type.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := c.decodeStruct(namespace, nestedPrefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		val, ok := c.value(namespace, prefix+key)
+		if !ok && field.Type.Kind() == reflect.Slice {
+			if items, ok := c.indexedValues(namespace, prefix+key); ok {
+				if err := c.decodeSlice(items, fv); err != nil {
+					return &ValueError{Namespace: namespace, Key: prefix + key, Value: strings.Join(items, c.separator()), Type: field.Type.String(), Err: err}
+				}
+				continue
+			}
+		}
+		if !ok {
+			if !hasDefault {
+				continue
+			}
+			val = defaultValue
+		}
+		if err := c.decodeValue(val, fv); err != nil {
+			return &ValueError{Namespace: namespace, Key: prefix + key, Value: val, Type: field.Type.String(), Err: err}
+		}
+	}
+	return nil
+}
+
+func (c *Client) decodeValue(val string, fv reflect.Value) error {
+	if fv.Type() == durationType {
+		d, err := c.parseDuration(val)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		b, err := c.parseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := c.parseInt64(val)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("数值溢出 -> %s", val)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := c.parseInt64(val)
+		if err != nil {
+			return err
+		}
+		if n < 0 || fv.OverflowUint(uint64(n)) {
+			return fmt.Errorf("数值溢出 -> %s", val)
+		}
+		fv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := c.parseFloat64(val)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		items, err := c.parseStringSlice(val)
+		if err != nil {
+			return err
+		}
+		return c.decodeSlice(items, fv)
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("不支持的字典类型 -> %s", fv.Type())
+		}
+		items, err := c.parseStringMap(val)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(fv.Type(), len(items))
+		for k, item := range items {
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if err := c.decodeValue(item, ev); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()), ev)
+		}
+		fv.Set(m)
+	case reflect.Ptr:
+		ev := reflect.New(fv.Type().Elem())
+		if err := c.decodeValue(val, ev.Elem()); err != nil {
+			return err
+		}
+		fv.Set(ev)
+	default:
+		return fmt.Errorf("不支持的字段类型 -> %s", fv.Type())
+	}
+	return nil
+}
+
+func (c *Client) decodeSlice(items []string, fv reflect.Value) error {
+	slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := c.decodeValue(item, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	fv.Set(slice)
+	return nil
+}
+
+// parseTag 解析 apollo 标签，默认值必须放在最后，可以包含逗号.
+func parseTag(field reflect.StructField) (key, defaultValue string, hasDefault bool) {
+	tag := field.Tag.Get(tagName)
+	if i := strings.Index(tag, ",default="); i >= 0 {
+		defaultValue = tag[i+len(",default="):]
+		hasDefault = true
+		tag = tag[:i]
+	}
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	key = strings.TrimSpace(tag)
+	if key == "" {
+		key = field.Name
+	}
+	return key, defaultValue, hasDefault
+}
+
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+// Binding 保存绑定到命名空间的结构体，配置变更时会重新解析并原子替换.
+type Binding struct {
+	namespace string
+	typ       reflect.Type
+	value     atomic.Value
+}
+
+// Load 获取最新解析的结构体指针，类型与 Bind 时传入的一致.
+func (b *Binding) Load() interface{} {
+	return b.value.Load()
+}
+
+// Namespace 获取绑定的命名空间.
+func (b *Binding) Namespace() string {
+	return b.namespace
+}
+
+// Bind 将命名空间解析到 v 中并保持绑定，之后每次该命名空间发生变更都会解析出新的结构体并替换，
+// 通过 Binding.Load 获取最新值. 重新解析失败时保留上一次的结果.
+func (c *Client) Bind(namespace string, v interface{}) (*Binding, error) {
+	if err := c.Unmarshal(namespace, v); err != nil {
+		return nil, err
+	}
+	b := &Binding{namespace: namespace, typ: reflect.TypeOf(v).Elem()}
+	b.value.Store(v)
+
+	c.rmx.Lock()
+	c.bindings = append(c.bindings, b)
+	c.rmx.Unlock()
+	return b, nil
+}
+
+// rebind 重新解析绑定到指定命名空间的结构体.
+func (c *Client) rebind(namespace string) {
+	c.rmx.RLock()
+	bindings := make([]*Binding, 0, len(c.bindings))
+	for _, b := range c.bindings {
+		if b.namespace == namespace {
+			bindings = append(bindings, b)
+		}
+	}
+	c.rmx.RUnlock()
+
+	for _, b := range bindings {
+		v := reflect.New(b.typ)
+		if err := c.Unmarshal(namespace, v.Interface()); err != nil {
+			c.log().Printf("重新解析绑定结构体失败 -> %s - %s - %s", namespace, b.typ, c.redact(err))
+			continue
+		}
+		b.value.Store(v.Interface())
+	}
+}
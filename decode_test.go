@@ -0,0 +1,88 @@
+package goapollo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testDatabase struct {
+	Host    string        `apollo:"host"`
+	Port    int           `apollo:"port,default=3306"`
+	Timeout time.Duration `apollo:"timeout,default=3s"`
+}
+
+type testConfig struct {
+	Name     string            `apollo:"app.name"`
+	Debug    bool              `apollo:"debug"`
+	Ratio    float64           `apollo:"ratio,default=0.5"`
+	Hosts    []string          `apollo:"hosts,default=a.com,b.com"`
+	Ports    []int             `apollo:"ports"`
+	Labels   map[string]string `apollo:"labels"`
+	DB       testDatabase      `apollo:"db"`
+	Cache    *testDatabase     `apollo:"cache"`
+	Ignored  string            `apollo:"-"`
+	internal string
+}
+
+func TestClient_Unmarshal(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{
+		"app.name":   "sample",
+		"debug":      "true",
+		"ports":      "80,443",
+		"labels":     "env=prod",
+		"db.host":    "127.0.0.1",
+		"db.timeout": "10s",
+		"cache.host": "redis",
+		"cache.port": "6379",
+		"Ignored":    "value",
+	}})
+
+	var conf testConfig
+	if err := c.Unmarshal(defaultNamespace, &conf); err != nil {
+		t.Fatalf("解析结构体失败 -> %s", err)
+	}
+	expected := testConfig{
+		Name:   "sample",
+		Debug:  true,
+		Ratio:  0.5,
+		Hosts:  []string{"a.com", "b.com"},
+		Ports:  []int{80, 443},
+		Labels: map[string]string{"env": "prod"},
+		DB:     testDatabase{Host: "127.0.0.1", Port: 3306, Timeout: 10 * time.Second},
+		Cache:  &testDatabase{Host: "redis", Port: 6379, Timeout: 3 * time.Second},
+	}
+	if !reflect.DeepEqual(conf, expected) {
+		t.Fatalf("解析结果错误 -> %+v", conf)
+	}
+
+	mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"db.port": "abc"}})
+	if err := c.Unmarshal(defaultNamespace, &conf); err == nil {
+		t.Fatalf("期望返回解析错误")
+	}
+	if err := c.Unmarshal(defaultNamespace, conf); err == nil {
+		t.Fatalf("非指针应返回错误")
+	}
+}
+
+func TestClient_Bind(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"host": "127.0.0.1"}})
+
+	b, err := c.Bind(defaultNamespace, &testDatabase{})
+	if err != nil {
+		t.Fatalf("绑定失败 -> %s", err)
+	}
+	first := b.Load().(*testDatabase)
+
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"host": "10.0.0.1", "port": "3307"}}))
+
+	current := b.Load().(*testDatabase)
+	if current.Host != "10.0.0.1" || current.Port != 3307 {
+		t.Fatalf("绑定结构体未更新 -> %+v", current)
+	}
+	if first.Host != "127.0.0.1" {
+		t.Fatalf("旧的结构体不应被修改 -> %+v", first)
+	}
+}
@@ -0,0 +1,178 @@
+package goapollo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	encryptedPrefix = "ENC("
+	encryptedSuffix = ")"
+	// redactedValue 日志中代替解密后明文的值.
+	redactedValue = "******"
+)
+
+// Decryptor 解密配置值，ciphertext 为 ENC(...) 中 base64 解码后的内容.
+type Decryptor interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// DecryptorFunc 将函数转换为 Decryptor.
+type DecryptorFunc func(ciphertext []byte) ([]byte, error)
+
+func (f DecryptorFunc) Decrypt(ciphertext []byte) ([]byte, error) {
+	return f(ciphertext)
+}
+
+type aesGCMDecryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMDecryptor 创建 AES-GCM 解密器，key 的长度为 16、24 或 32 字节，密文格式为 12 字节的 nonce 加上加密结果.
+func NewAESGCMDecryptor(key []byte) (Decryptor, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMDecryptor{aead: aead}, nil
+}
+
+func (d *aesGCMDecryptor) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := d.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("密文长度不正确")
+	}
+	return d.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
+
+type rsaDecryptor struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSADecryptor 创建使用 SHA-256 的 RSA-OAEP 解密器.
+func NewRSADecryptor(key *rsa.PrivateKey) Decryptor {
+	return &rsaDecryptor{key: key}
+}
+
+// NewRSADecryptorFromPEM 使用 PEM 格式的 PKCS#1 或 PKCS#8 私钥创建 RSA-OAEP 解密器.
+func NewRSADecryptorFromPEM(body []byte) (Decryptor, error) {
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, errors.New("解析 PEM 私钥失败")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewRSADecryptor(key), nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("私钥不是 RSA 私钥")
+	}
+	return NewRSADecryptor(rsaKey), nil
+}
+
+func (d *rsaDecryptor) Decrypt(ciphertext []byte) ([]byte, error) {
+	return rsa.DecryptOAEP(sha256.New(), nil, d.key, ciphertext, nil)
+}
+
+// SetDecryptor 设置解密器，读取配置时自动解密 ENC(base64密文) 格式的值，需要在读取配置前设置.
+// 解密后的明文只保存在内存中，不会写入备份文件、变更事件和日志.
+func (c *Client) SetDecryptor(decryptor Decryptor) {
+	c.decryptMux.Lock()
+	c.decryptor = decryptor
+	c.plaintexts = &sync.Map{}
+	c.decryptMux.Unlock()
+}
+
+// decryption 获取当前的解密器和明文缓存.
+func (c *Client) decryption() (Decryptor, *sync.Map) {
+	c.decryptMux.RLock()
+	defer c.decryptMux.RUnlock()
+	return c.decryptor, c.plaintexts
+}
+
+// encrypted 判断配置值是否为 ENC(...) 格式.
+func encrypted(val string) bool {
+	return strings.HasPrefix(val, encryptedPrefix) && strings.HasSuffix(val, encryptedSuffix)
+}
+
+// decrypt 解密 ENC(...) 格式的值，结果按密文缓存在 plaintexts 中.
+func decrypt(decryptor Decryptor, plaintexts *sync.Map, val string) (string, error) {
+	if plaintext, ok := plaintexts.Load(val); ok {
+		return plaintext.(string), nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(val[len(encryptedPrefix) : len(val)-len(encryptedSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("解码密文失败 -> %s", err)
+	}
+	plaintext, err := decryptor.Decrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("解密失败 -> %s", err)
+	}
+	plaintexts.Store(val, string(plaintext))
+	return string(plaintext), nil
+}
+
+// decrypted 获取原始值并解密，未设置解密器或解密失败时返回原始值，日志中不会输出配置值.
+func (c *Client) decrypted(namespace, key string) (string, bool) {
+	val, ok := c.caches.get(namespace, key)
+	if !ok || !encrypted(val) {
+		return val, ok
+	}
+	decryptor, plaintexts := c.decryption()
+	if decryptor == nil {
+		return val, true
+	}
+	plaintext, err := decrypt(decryptor, plaintexts, val)
+	if err != nil {
+		c.log().Printf("解密配置失败 -> %s - %s - %s", namespace, key, err)
+		return val, true
+	}
+	return plaintext, true
+}
+
+// fromEncrypted 判断配置值是否来自解密后的密文，包括占位符引用的密文和 key[0] 形式的列表元素.
+func (c *Client) fromEncrypted(namespace, key string) bool {
+	if decryptor, _ := c.decryption(); decryptor == nil {
+		return false
+	}
+	if raw, ok := c.resolveValue(namespace, key, c.caches.get); ok {
+		val, _ := c.value(namespace, key)
+		return val != raw
+	}
+	for i := 0; ; i++ {
+		item := key + "[" + strconv.Itoa(i) + "]"
+		if _, ok := c.caches.get(namespace, item); !ok {
+			return false
+		}
+		if c.fromEncrypted(namespace, item) {
+			return true
+		}
+	}
+}
+
+// redact 隐藏来自密文的配置值及包含该值的错误信息，避免明文出现在日志中.
+func (c *Client) redact(err error) error {
+	e, ok := err.(*ValueError)
+	if !ok || !c.fromEncrypted(e.Namespace, e.Key) {
+		return err
+	}
+	return &ValueError{Namespace: e.Namespace, Key: e.Key, Value: redactedValue, Type: e.Type, Err: errors.New("配置值来自密文，已隐藏错误详情")}
+}
@@ -3,34 +3,29 @@ package goapollo
 import (
 	"context"
 	"errors"
-	"os"
-	"strings"
+	"time"
 )
 
 var defaultClient *Client
 
-func Run(ctx context.Context) error {
-	host := os.Getenv("APOLLO_HOST")
-	appId := os.Getenv("APOLLO_APP_ID")
-	cluster := os.Getenv("APOLLO_CLUSTER")
-	namespace := os.Getenv("APOLLO_NAMESPACE")
+var errClientNotInitialized = errors.New("默认客户端未初始化")
 
-	if host == "" || appId == "" {
-		return errors.New("配置不完整")
-	}
-	if cluster == "" {
-		cluster = "default"
+// Run 使用 LoadConfig 加载的配置初始化并启动默认客户端，未配置命名空间时使用 application.
+func Run(ctx context.Context, opts ...Option) error {
+	conf, err := LoadConfig("", opts...)
+	if err != nil {
+		logger.Printf("加载 Apollo 客户端配置失败 ->%s", err)
+		return err
 	}
-	var namespaces []string
-	if namespace == "" {
-		namespaces = append(namespaces, defaultNamespace)
-	} else {
-		namespaces = strings.Split(namespace, ";")
+	if len(conf.Namespaces) == 0 {
+		conf.Namespaces = []string{defaultNamespace}
 	}
-	defaultClient = New(host, appId, cluster)
-	for _, s := range namespaces {
-		defaultClient.AddNamespace(s)
+	client, err := NewWithConfig(conf)
+	if err != nil {
+		logger.Printf("创建 Apollo 客户端失败 ->%s", err)
+		return err
 	}
+	defaultClient = client
 
 	if err := defaultClient.Run(ctx); err != nil {
 		logger.Printf("启动 Apollo 客户端失败 ->%s", err)
@@ -77,3 +72,199 @@ func Close() {
 		_ = defaultClient.Close()
 	}
 }
+
+func GetInt(key string, defaultValue int) int {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetInt(key, defaultValue)
+}
+
+func GetIntWithNamespace(namespace, key string, defaultValue int) int {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetIntWithNamespace(namespace, key, defaultValue)
+}
+
+func GetIntE(key string) (int, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetIntE(key)
+}
+
+func GetIntWithNamespaceE(namespace, key string) (int, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetIntWithNamespaceE(namespace, key)
+}
+
+func GetInt64(key string, defaultValue int64) int64 {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetInt64(key, defaultValue)
+}
+
+func GetInt64WithNamespace(namespace, key string, defaultValue int64) int64 {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetInt64WithNamespace(namespace, key, defaultValue)
+}
+
+func GetInt64E(key string) (int64, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetInt64E(key)
+}
+
+func GetInt64WithNamespaceE(namespace, key string) (int64, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetInt64WithNamespaceE(namespace, key)
+}
+
+func GetBool(key string, defaultValue bool) bool {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetBool(key, defaultValue)
+}
+
+func GetBoolWithNamespace(namespace, key string, defaultValue bool) bool {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetBoolWithNamespace(namespace, key, defaultValue)
+}
+
+func GetBoolE(key string) (bool, error) {
+	if defaultClient == nil {
+		return false, errClientNotInitialized
+	}
+	return defaultClient.GetBoolE(key)
+}
+
+func GetBoolWithNamespaceE(namespace, key string) (bool, error) {
+	if defaultClient == nil {
+		return false, errClientNotInitialized
+	}
+	return defaultClient.GetBoolWithNamespaceE(namespace, key)
+}
+
+func GetFloat64(key string, defaultValue float64) float64 {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetFloat64(key, defaultValue)
+}
+
+func GetFloat64WithNamespace(namespace, key string, defaultValue float64) float64 {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetFloat64WithNamespace(namespace, key, defaultValue)
+}
+
+func GetFloat64E(key string) (float64, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetFloat64E(key)
+}
+
+func GetFloat64WithNamespaceE(namespace, key string) (float64, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetFloat64WithNamespaceE(namespace, key)
+}
+
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetDuration(key, defaultValue)
+}
+
+func GetDurationWithNamespace(namespace, key string, defaultValue time.Duration) time.Duration {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetDurationWithNamespace(namespace, key, defaultValue)
+}
+
+func GetDurationE(key string) (time.Duration, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetDurationE(key)
+}
+
+func GetDurationWithNamespaceE(namespace, key string) (time.Duration, error) {
+	if defaultClient == nil {
+		return 0, errClientNotInitialized
+	}
+	return defaultClient.GetDurationWithNamespaceE(namespace, key)
+}
+
+func GetStringSlice(key string, defaultValue []string) []string {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetStringSlice(key, defaultValue)
+}
+
+func GetStringSliceWithNamespace(namespace, key string, defaultValue []string) []string {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetStringSliceWithNamespace(namespace, key, defaultValue)
+}
+
+func GetStringSliceE(key string) ([]string, error) {
+	if defaultClient == nil {
+		return nil, errClientNotInitialized
+	}
+	return defaultClient.GetStringSliceE(key)
+}
+
+func GetStringSliceWithNamespaceE(namespace, key string) ([]string, error) {
+	if defaultClient == nil {
+		return nil, errClientNotInitialized
+	}
+	return defaultClient.GetStringSliceWithNamespaceE(namespace, key)
+}
+
+func GetStringMap(key string, defaultValue map[string]string) map[string]string {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetStringMap(key, defaultValue)
+}
+
+func GetStringMapWithNamespace(namespace, key string, defaultValue map[string]string) map[string]string {
+	if defaultClient == nil {
+		return defaultValue
+	}
+	return defaultClient.GetStringMapWithNamespace(namespace, key, defaultValue)
+}
+
+func GetStringMapE(key string) (map[string]string, error) {
+	if defaultClient == nil {
+		return nil, errClientNotInitialized
+	}
+	return defaultClient.GetStringMapE(key)
+}
+
+func GetStringMapWithNamespaceE(namespace, key string) (map[string]string, error) {
+	if defaultClient == nil {
+		return nil, errClientNotInitialized
+	}
+	return defaultClient.GetStringMapWithNamespaceE(namespace, key)
+}
@@ -0,0 +1,195 @@
+package goapollo
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// DeliveryMode 变更事件通道已满时的投递方式.
+type DeliveryMode int
+
+const (
+	// DeliveryDrop 通道已满时丢弃事件，默认方式.
+	DeliveryDrop DeliveryMode = iota
+	// DeliveryBlock 通道已满时阻塞等待消费者，配置同步会随之暂停.
+	DeliveryBlock
+	// DeliveryCoalesce 通道已满时将同一命名空间的事件合并为最新状态，消费者空闲后再投递.
+	DeliveryCoalesce
+)
+
+func (m DeliveryMode) String() string {
+	switch m {
+	case DeliveryDrop:
+		return "DROP"
+	case DeliveryBlock:
+		return "BLOCK"
+	case DeliveryCoalesce:
+		return "COALESCE"
+	}
+	return "UNKNOWN"
+}
+
+// EventStats 变更事件投递统计.
+type EventStats struct {
+	// Delivered 已写入通道的事件数.
+	Delivered uint64
+	// Dropped 被丢弃的事件数.
+	Dropped uint64
+	// Coalesced 被合并到其他事件中的事件数.
+	Coalesced uint64
+}
+
+// SetDeliveryMode 设置变更事件的投递方式，需要在 Run 之前调用.
+func (c *Client) SetDeliveryMode(mode DeliveryMode) {
+	c.deliveryMode = mode
+}
+
+// EventStats 获取变更事件的投递统计，可用于监控丢弃和合并的事件.
+func (c *Client) EventStats() EventStats {
+	return EventStats{
+		Delivered: atomic.LoadUint64(&c.delivered),
+		Dropped:   atomic.LoadUint64(&c.dropped),
+		Coalesced: atomic.LoadUint64(&c.coalesced),
+	}
+}
+
+// deliver 按投递方式将事件写入通道.
+func (c *Client) deliver(event *ChangeEvent) {
+	switch c.deliveryMode {
+	case DeliveryBlock:
+		select {
+		case c.eventCh <- event:
+			atomic.AddUint64(&c.delivered, 1)
+		case <-c.ctx.Done():
+			atomic.AddUint64(&c.dropped, 1)
+		}
+	case DeliveryCoalesce:
+		c.coalesce(event)
+	default:
+		select {
+		case c.eventCh <- event:
+			atomic.AddUint64(&c.delivered, 1)
+		default:
+			atomic.AddUint64(&c.dropped, 1)
+			c.log().Printf("事件通道已满，丢弃变更事件 -> %s", event.Namespace)
+		}
+	}
+}
+
+// coalesce 有待投递的事件时合并或排队，否则直接写入通道.
+func (c *Client) coalesce(event *ChangeEvent) {
+	c.pendingMux.Lock()
+	defer c.pendingMux.Unlock()
+
+	if pending, ok := c.pending[event.Namespace]; ok {
+		mergeEvent(pending, event)
+		atomic.AddUint64(&c.coalesced, 1)
+		// 合并后相互抵消的事件不再投递
+		if len(pending.Changes) == 0 {
+			c.removePending(event.Namespace)
+		}
+		return
+	}
+	// forward 取出但尚未投递的同一命名空间的事件需要先投递，新的事件只能排队
+	if len(c.pendingQueue) == 0 && c.inflight != event.Namespace {
+		select {
+		case c.eventCh <- event:
+			atomic.AddUint64(&c.delivered, 1)
+			return
+		default:
+		}
+	}
+	c.pending[event.Namespace] = cloneEvent(event)
+	c.pendingQueue = append(c.pendingQueue, event.Namespace)
+
+	select {
+	case c.pendingSignal <- struct{}{}:
+	default:
+	}
+}
+
+// removePending 移除排队中的事件，调用方需要持有 pendingMux.
+func (c *Client) removePending(namespace string) {
+	delete(c.pending, namespace)
+	for i, ns := range c.pendingQueue {
+		if ns == namespace {
+			c.pendingQueue = append(c.pendingQueue[:i], c.pendingQueue[i+1:]...)
+			break
+		}
+	}
+}
+
+// popPending 取出最早排队的事件，并记录为正在投递的事件.
+func (c *Client) popPending() *ChangeEvent {
+	c.pendingMux.Lock()
+	defer c.pendingMux.Unlock()
+
+	c.inflight = ""
+	if len(c.pendingQueue) == 0 {
+		return nil
+	}
+	namespace := c.pendingQueue[0]
+	c.pendingQueue = c.pendingQueue[1:]
+	event := c.pending[namespace]
+	delete(c.pending, namespace)
+	c.inflight = namespace
+	return event
+}
+
+// forward 将排队的事件依次写入通道，直到 ctx 结束.
+func (c *Client) forward(ctx context.Context) {
+	for {
+		select {
+		case <-c.pendingSignal:
+		case <-ctx.Done():
+			return
+		}
+		for event := c.popPending(); event != nil; event = c.popPending() {
+			select {
+			case c.eventCh <- event:
+				atomic.AddUint64(&c.delivered, 1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func cloneEvent(event *ChangeEvent) *ChangeEvent {
+	clone := &ChangeEvent{Namespace: event.Namespace, Changes: make(map[string]*Change, len(event.Changes))}
+	for key, change := range event.Changes {
+		c := *change
+		clone.Changes[key] = &c
+	}
+	return clone
+}
+
+// mergeEvent 将 src 合并到 dst 中，合并后的变更反映从 dst 之前到 src 之后的状态.
+func mergeEvent(dst, src *ChangeEvent) {
+	for key, change := range src.Changes {
+		prev, ok := dst.Changes[key]
+		if !ok {
+			c := *change
+			dst.Changes[key] = &c
+			continue
+		}
+		merged := &Change{OldValue: prev.OldValue, NewValue: change.NewValue}
+		switch {
+		case prev.ChangeType == EventAdd && change.ChangeType == EventDelete:
+			delete(dst.Changes, key)
+			continue
+		case prev.ChangeType == EventAdd:
+			merged.ChangeType = EventAdd
+		case change.ChangeType == EventDelete:
+			merged.ChangeType = EventDelete
+			merged.NewValue = ""
+		default:
+			merged.ChangeType = EventModify
+		}
+		if merged.ChangeType == EventModify && merged.OldValue == merged.NewValue {
+			delete(dst.Changes, key)
+			continue
+		}
+		dst.Changes[key] = merged
+	}
+}
@@ -0,0 +1,155 @@
+package goapollo
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClient_DeliveryCoalesce(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	c.eventCh = make(chan *ChangeEvent, 1)
+	c.SetDeliveryMode(DeliveryCoalesce)
+
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1", "z": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "2", "z": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "3", "y": "1"}}))
+
+	if stats := c.EventStats(); stats.Delivered != 1 || stats.Coalesced != 1 || stats.Dropped != 0 {
+		t.Fatalf("投递统计错误 -> %+v", stats)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.forward(ctx)
+
+	if event := <-c.eventCh; event.Changes["x"].NewValue != "1" {
+		t.Fatalf("第一个事件错误 -> %s", event)
+	}
+	select {
+	case event := <-c.eventCh:
+		x, y, z := event.Changes["x"], event.Changes["y"], event.Changes["z"]
+		if x == nil || x.ChangeType != EventModify || x.OldValue != "1" || x.NewValue != "3" {
+			t.Fatalf("合并后的修改错误 -> %s", event)
+		}
+		if y == nil || y.ChangeType != EventAdd || z == nil || z.ChangeType != EventDelete || len(event.Changes) != 3 {
+			t.Fatalf("合并后的事件错误 -> %s", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("等待合并事件超时")
+	}
+}
+
+func TestClient_DeliveryCoalesceCancel(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	c.eventCh = make(chan *ChangeEvent, 1)
+	c.SetDeliveryMode(DeliveryCoalesce)
+
+	// 排队中新增的配置随后又被删除，合并后没有变更
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1", "y": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1"}}))
+
+	if len(c.pending) != 0 || len(c.pendingQueue) != 0 {
+		t.Fatalf("相互抵消的事件不应继续排队 -> %d", len(c.pendingQueue))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.forward(ctx)
+
+	<-c.eventCh
+	select {
+	case event := <-c.eventCh:
+		t.Fatalf("不应投递空的事件 -> %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_DeliveryCoalesceInflight(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	c.eventCh = make(chan *ChangeEvent, 1)
+	c.SetDeliveryMode(DeliveryCoalesce)
+
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "2"}}))
+
+	// 模拟 forward 已取出排队的事件但还未投递时，通道空出了位置
+	inflight := c.popPending()
+	<-c.eventCh
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "3"}}))
+	select {
+	case event := <-c.eventCh:
+		t.Fatalf("新的事件不应先于正在投递的事件 -> %s", event)
+	default:
+	}
+	if inflight.Changes["x"].NewValue != "2" || len(c.pendingQueue) != 1 {
+		t.Fatalf("新的事件应排队等待投递 -> %s", inflight)
+	}
+}
+
+func TestClient_DeliveryCoalesceOrder(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	c.eventCh = make(chan *ChangeEvent, 1)
+	c.SetDeliveryMode(DeliveryCoalesce)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.forward(ctx)
+
+	const total = 500
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= total; i++ {
+			event, _ := c.caches.store(result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": strconv.Itoa(i)}})
+			c.publish(event)
+		}
+	}()
+
+	// 通道一直处于满的状态，消费者收到的值不应回退
+	last := 0
+	for last < total {
+		select {
+		case event := <-c.eventCh:
+			n, _ := strconv.Atoi(event.Changes["x"].NewValue)
+			if n <= last {
+				t.Fatalf("事件顺序错误 -> %d - %d", last, n)
+			}
+			last = n
+		case <-time.After(2 * time.Second):
+			t.Fatalf("等待事件超时 -> %d", last)
+		}
+	}
+	<-done
+}
+
+func TestClient_DeliveryDrop(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	c.eventCh = make(chan *ChangeEvent, 1)
+
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "1"}}))
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"x": "2"}}))
+
+	if stats := c.EventStats(); stats.Delivered != 1 || stats.Dropped != 1 {
+		t.Fatalf("投递统计错误 -> %+v", stats)
+	}
+}
+
+func TestMergeEvent(t *testing.T) {
+	dst := &ChangeEvent{Namespace: defaultNamespace, Changes: map[string]*Change{
+		"added":    {NewValue: "1", ChangeType: EventAdd},
+		"modified": {OldValue: "1", NewValue: "2", ChangeType: EventModify},
+		"deleted":  {OldValue: "1", ChangeType: EventDelete},
+	}}
+	mergeEvent(dst, &ChangeEvent{Namespace: defaultNamespace, Changes: map[string]*Change{
+		"added":    {OldValue: "1", ChangeType: EventDelete},
+		"modified": {OldValue: "2", NewValue: "1", ChangeType: EventModify},
+		"deleted":  {NewValue: "2", ChangeType: EventAdd},
+	}})
+
+	if len(dst.Changes) != 1 {
+		t.Fatalf("合并结果错误 -> %s", dst)
+	}
+	if change := dst.Changes["deleted"]; change.ChangeType != EventModify || change.OldValue != "1" || change.NewValue != "2" {
+		t.Fatalf("删除后新增应合并为修改 -> %s", dst)
+	}
+}
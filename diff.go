@@ -0,0 +1,284 @@
+package goapollo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// diffContext 统一格式差异中每个变更前后保留的行数.
+const diffContext = 3
+
+// DiffReport 两个配置快照之间的差异，From 和 To 为两个快照的名称.
+type DiffReport struct {
+	From    string
+	To      string
+	Changes map[string]*Change
+
+	oldValues map[string]string
+	newValues map[string]string
+}
+
+// Diff 比较两个配置快照，yaml 和 json 格式的命名空间会展开后逐个键比较.
+// 返回结果的 From 和 To 默认为命名空间名称，调用方可以修改为集群或文件名.
+func Diff(from, to *Configuration) *DiffReport {
+	oldValues, newValues := configurationValues(from), configurationValues(to)
+	report := &DiffReport{
+		From:      from.NamespaceName,
+		To:        to.NamespaceName,
+		Changes:   make(map[string]*Change),
+		oldValues: oldValues,
+		newValues: newValues,
+	}
+	for key, oldValue := range oldValues {
+		if newValue, ok := newValues[key]; !ok {
+			report.Changes[key] = &Change{OldValue: oldValue, ChangeType: EventDelete}
+		} else if newValue != oldValue {
+			report.Changes[key] = &Change{OldValue: oldValue, NewValue: newValue, ChangeType: EventModify}
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			report.Changes[key] = &Change{NewValue: newValue, ChangeType: EventAdd}
+		}
+	}
+	return report
+}
+
+// configurationValues 获取快照的键值对，结构化文档无法解析时按原始内容比较.
+func configurationValues(config *Configuration) map[string]string {
+	format := NamespaceFormat(config.NamespaceName)
+	if content, ok := config.Configurations[contentKey]; ok && format.structured() {
+		if values, err := flatten(format, content); err == nil {
+			return values
+		}
+	}
+	return config.Configurations
+}
+
+// Empty 两个快照是否完全相同.
+func (r *DiffReport) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+func (r *DiffReport) keys() []string {
+	keys := make([]string, 0, len(r.Changes))
+	for key := range r.Changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Text 以文本格式输出差异，每个变更一行.
+func (r *DiffReport) Text() string {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "%s -> %s\n", r.From, r.To)
+	if r.Empty() {
+		buf.WriteString("无差异\n")
+		return buf.String()
+	}
+	for _, key := range r.keys() {
+		change := r.Changes[key]
+		switch change.ChangeType {
+		case EventAdd:
+			_, _ = fmt.Fprintf(&buf, "%-7s %s = %s\n", change.ChangeType, key, change.NewValue)
+		case EventDelete:
+			_, _ = fmt.Fprintf(&buf, "%-7s %s = %s\n", change.ChangeType, key, change.OldValue)
+		default:
+			_, _ = fmt.Fprintf(&buf, "%-7s %s = %s -> %s\n", change.ChangeType, key, change.OldValue, change.NewValue)
+		}
+	}
+	return buf.String()
+}
+
+type diffItem struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+// MarshalJSON 以 JSON 格式输出差异，变更按键排序，变更类型为 ADD、MODIFY 或 DELETE.
+func (r *DiffReport) MarshalJSON() ([]byte, error) {
+	items := make([]diffItem, 0, len(r.Changes))
+	for _, key := range r.keys() {
+		change := r.Changes[key]
+		items = append(items, diffItem{Key: key, Type: change.ChangeType.String(), OldValue: change.OldValue, NewValue: change.NewValue})
+	}
+	return json.Marshal(struct {
+		From    string     `json:"from"`
+		To      string     `json:"to"`
+		Changes []diffItem `json:"changes"`
+	}{r.From, r.To, items})
+}
+
+// Unified 以统一格式（unified diff）输出差异，每个配置项为一行 key=value，按键排序.
+func (r *DiffReport) Unified() string {
+	type line struct {
+		op   byte
+		text string
+	}
+	oldValues, newValues := r.oldValues, r.newValues
+	if oldValues == nil && newValues == nil {
+		// 不是通过 Diff 创建时只输出有差异的键
+		oldValues, newValues = make(map[string]string), make(map[string]string)
+		for key, change := range r.Changes {
+			if change.ChangeType != EventAdd {
+				oldValues[key] = change.OldValue
+			}
+			if change.ChangeType != EventDelete {
+				newValues[key] = change.NewValue
+			}
+		}
+	}
+	keys := make([]string, 0, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys = append(keys, key)
+	}
+	for key := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []line
+	for _, key := range keys {
+		oldValue, inOld := oldValues[key]
+		newValue, inNew := newValues[key]
+		if inOld && inNew && oldValue == newValue {
+			lines = append(lines, line{' ', key + "=" + oldValue})
+			continue
+		}
+		if inOld {
+			lines = append(lines, line{'-', key + "=" + oldValue})
+		}
+		if inNew {
+			lines = append(lines, line{'+', key + "=" + newValue})
+		}
+	}
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "--- %s\n+++ %s\n", r.From, r.To)
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+		// 合并相距不超过两倍上下文的变更
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines) && j-end <= 2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				end = j
+			}
+		}
+		stop := end + diffContext + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		oldCount, newCount := 0, 0
+		var hunk bytes.Buffer
+		for _, l := range lines[start:stop] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+			_, _ = fmt.Fprintf(&hunk, "%c%s\n", l.op, l.text)
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		_, _ = fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		buf.Write(hunk.Bytes())
+
+		for _, l := range lines[i:stop] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		i = stop
+	}
+	return buf.String()
+}
+
+// ReadBackup 读取并校验本地备份文件，返回其中保存的配置快照.
+func ReadBackup(path string, serializer Serializer) (*Configuration, error) {
+	body, err := readBackup(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Configuration
+	if err := serializer.Deserialize(body, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Snapshot 从配置服务获取指定集群下命名空间的最新配置，不会修改客户端缓存，cluster 为空时使用客户端的集群.
+func (c *Client) Snapshot(cluster, namespace string) (*Configuration, error) {
+	if cluster == "" {
+		cluster = c.cluster
+	}
+	result, err := c.fetch(cluster, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("配置服务未返回配置")
+	}
+	return &Configuration{NamespaceName: namespace, Configurations: result.Configurations, ReleaseKey: result.ReleaseKey}, nil
+}
+
+// DiffClusters 比较两个集群下同一命名空间的最新配置.
+func (c *Client) DiffClusters(namespace, from, to string) (*DiffReport, error) {
+	fromConfig, err := c.Snapshot(from, namespace)
+	if err != nil {
+		return nil, err
+	}
+	toConfig, err := c.Snapshot(to, namespace)
+	if err != nil {
+		return nil, err
+	}
+	report := Diff(fromConfig, toConfig)
+	report.From, report.To = from, to
+	return report, nil
+}
+
+// DiffBackup 比较本地备份文件与配置服务上客户端所在集群的最新配置，serializer 为 nil 时使用 json 序列化器.
+func (c *Client) DiffBackup(namespace, path string, serializer Serializer) (*DiffReport, error) {
+	if serializer == nil {
+		serializer = NewJsonSerializer()
+	}
+	backup, err := ReadBackup(path, serializer)
+	if err != nil {
+		return nil, err
+	}
+	backup.NamespaceName = namespace
+	latest, err := c.Snapshot("", namespace)
+	if err != nil {
+		return nil, err
+	}
+	report := Diff(backup, latest)
+	report.From, report.To = path, c.cluster
+	return report, nil
+}
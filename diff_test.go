@@ -0,0 +1,118 @@
+package goapollo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lifei6671/goapollo/apollotest"
+)
+
+func TestDiff(t *testing.T) {
+	from := &Configuration{NamespaceName: "application", Configurations: map[string]string{"a": "1", "b": "2", "c": "3"}}
+	to := &Configuration{NamespaceName: "application", Configurations: map[string]string{"a": "1", "b": "20", "d": "4"}}
+	report := Diff(from, to)
+	report.From, report.To = "default", "SHAJQ"
+
+	expected := "default -> SHAJQ\n" +
+		"MODIFY  b = 2 -> 20\n" +
+		"DELETE  c = 3\n" +
+		"ADD     d = 4\n"
+	if text := report.Text(); text != expected {
+		t.Fatalf("文本格式错误 -> \n%s", text)
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"from":"default","to":"SHAJQ","changes":[` +
+		`{"key":"b","type":"MODIFY","old_value":"2","new_value":"20"},` +
+		`{"key":"c","type":"DELETE","old_value":"3"},` +
+		`{"key":"d","type":"ADD","new_value":"4"}]}`
+	if string(body) != expected {
+		t.Fatalf("JSON 格式错误 -> %s", body)
+	}
+
+	expected = "--- default\n+++ SHAJQ\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" a=1\n" +
+		"-b=2\n" +
+		"+b=20\n" +
+		"-c=3\n" +
+		"+d=4\n"
+	if unified := report.Unified(); unified != expected {
+		t.Fatalf("统一格式错误 -> \n%s", unified)
+	}
+
+	if !Diff(from, from).Empty() {
+		t.Fatalf("相同快照不应有差异")
+	}
+}
+
+func TestDiff_UnifiedHunks(t *testing.T) {
+	from := &Configuration{NamespaceName: "application", Configurations: map[string]string{}}
+	to := &Configuration{NamespaceName: "application", Configurations: map[string]string{}}
+	for i := 10; i < 30; i++ {
+		from.Configurations["k"+strconv.Itoa(i)] = "v"
+		to.Configurations["k"+strconv.Itoa(i)] = "v"
+	}
+	to.Configurations["k10"] = "x"
+	delete(to.Configurations, "k25")
+
+	expected := "--- application\n+++ application\n" +
+		"@@ -1,4 +1,4 @@\n-k10=v\n+k10=x\n k11=v\n k12=v\n k13=v\n" +
+		"@@ -13,7 +13,6 @@\n k22=v\n k23=v\n k24=v\n-k25=v\n k26=v\n k27=v\n k28=v\n"
+	if unified := Diff(from, to).Unified(); unified != expected {
+		t.Fatalf("统一格式错误 -> \n%s", unified)
+	}
+}
+
+func TestDiff_Structured(t *testing.T) {
+	from := &Configuration{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: "db:\n  host: a\n  port: 3306\n"}}
+	to := &Configuration{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: "db:\n  host: b\n  port: 3306\n"}}
+	report := Diff(from, to)
+	if len(report.Changes) != 1 || report.Changes["db.host"] == nil || report.Changes["db.host"].NewValue != "b" {
+		t.Fatalf("结构化文档应展开后比较 -> %s", report.Text())
+	}
+}
+
+func TestClient_DiffClusters(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100", "name": "demo"})
+	server.Publish("SampleApp", "SHAJQ", "application", map[string]string{"timeout": "200", "name": "demo"})
+
+	c := New(server.URL, "SampleApp", "default")
+	report, err := c.DiffClusters("application", "default", "SHAJQ")
+	if err != nil {
+		t.Fatalf("比较集群失败 -> %s", err)
+	}
+	if change := report.Changes["timeout"]; len(report.Changes) != 1 || change.OldValue != "100" || change.NewValue != "200" {
+		t.Fatalf("集群差异错误 -> %s", report.Text())
+	}
+	if c.caches.has("application") {
+		t.Fatalf("比较集群不应修改客户端缓存")
+	}
+
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "application")
+	body, _ := NewJsonSerializer().Serialize(&Configuration{NamespaceName: "application", Configurations: map[string]string{"timeout": "100"}})
+	if err := writeBackup(path, body, true); err != nil {
+		t.Fatal(err)
+	}
+	report, err = c.DiffBackup("application", path, nil)
+	if err != nil {
+		t.Fatalf("比较备份文件失败 -> %s", err)
+	}
+	if change := report.Changes["name"]; len(report.Changes) != 1 || change.ChangeType != EventAdd {
+		t.Fatalf("备份文件差异错误 -> %s", report.Text())
+	}
+}
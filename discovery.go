@@ -0,0 +1,215 @@
+package goapollo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultServiceRefreshInterval = 5 * time.Minute
+
+var errNoConfigService = errors.New("没有可用的配置服务")
+
+type serviceDTO struct {
+	AppName     string `json:"appName"`
+	InstanceId  string `json:"instanceId"`
+	HomepageUrl string `json:"homepageUrl"`
+}
+
+// configServices 维护可用的配置服务地址，配置请求和通知请求共享同一个地址池.
+type configServices struct {
+	mux       *sync.RWMutex
+	metas     []string
+	metaIndex int
+	appId     string
+	services  []string
+	index     int
+	failures  int
+	client    *http.Client
+	logger    ILogger
+}
+
+// newStaticServices 使用固定的配置服务地址创建地址池.
+func newStaticServices(host string) *configServices {
+	return &configServices{
+		mux:      &sync.RWMutex{},
+		services: []string{strings.TrimRight(host, "/")},
+	}
+}
+
+// newMetaServices 创建通过 Meta Server 发现配置服务的地址池，多个 Meta Server 可用逗号分隔.
+func newMetaServices(meta, appId string) *configServices {
+	var metas []string
+	for _, s := range strings.Split(meta, ",") {
+		if s = strings.TrimRight(strings.TrimSpace(s), "/"); s != "" {
+			metas = append(metas, s)
+		}
+	}
+	return &configServices{
+		mux:   &sync.RWMutex{},
+		metas: metas,
+		appId: appId,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *configServices) log() ILogger {
+	return loggerOrDefault(s.logger)
+}
+
+// current 获取当前使用的配置服务地址.
+func (s *configServices) current() (string, error) {
+	s.mux.RLock()
+	if len(s.services) > 0 {
+		host := s.services[s.index%len(s.services)]
+		s.mux.RUnlock()
+		return host, nil
+	}
+	s.mux.RUnlock()
+
+	if len(s.metas) == 0 {
+		return "", errNoConfigService
+	}
+	if err := s.refresh(); err != nil {
+		return "", err
+	}
+	return s.current()
+}
+
+// size 获取地址池中的配置服务数量.
+func (s *configServices) size() int {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return len(s.services)
+}
+
+// failover 标记指定的配置服务不可用，后续请求将切换到下一个地址.
+// 列表中的配置服务都不可用时，立即从 Meta Server 重新获取配置服务列表.
+func (s *configServices) failover(host string) {
+	s.mux.Lock()
+	if len(s.services) == 0 || s.services[s.index%len(s.services)] != host {
+		s.mux.Unlock()
+		return
+	}
+	s.failures++
+	exhausted := s.failures >= len(s.services)
+	if len(s.services) > 1 {
+		s.index = (s.index + 1) % len(s.services)
+		s.log().Printf("配置服务不可用，切换地址 -> %s - %s", host, s.services[s.index])
+	}
+	s.mux.Unlock()
+
+	if exhausted && len(s.metas) > 0 {
+		s.log().Printf("配置服务都不可用，重新获取配置服务列表 -> %s", host)
+		if err := s.refresh(); err != nil {
+			s.log().Printf("刷新配置服务列表失败 -> %s", err)
+		}
+	}
+}
+
+// refresh 从 Meta Server 拉取最新的配置服务列表，Meta Server 不可用时依次尝试下一个.
+// succeed 配置服务请求成功后重置连续失败次数，避免间隔很久的偶发失败累计后触发不必要的刷新.
+func (s *configServices) succeed(host string) {
+	s.mux.Lock()
+	if len(s.services) > 0 && s.services[s.index%len(s.services)] == host {
+		s.failures = 0
+	}
+	s.mux.Unlock()
+}
+
+func (s *configServices) refresh() error {
+	if len(s.metas) == 0 {
+		return nil
+	}
+	var lastErr error
+	for i := 0; i < len(s.metas); i++ {
+		s.mux.RLock()
+		meta := s.metas[s.metaIndex%len(s.metas)]
+		s.mux.RUnlock()
+
+		services, err := s.fetch(meta)
+		if err == nil {
+			s.mux.Lock()
+			current := ""
+			if len(s.services) > 0 {
+				current = s.services[s.index%len(s.services)]
+			}
+			s.services = services
+			s.index = 0
+			s.failures = 0
+			for j, host := range services {
+				if host == current {
+					s.index = j
+					break
+				}
+			}
+			s.mux.Unlock()
+			return nil
+		}
+		s.log().Printf("获取配置服务列表失败 -> %s - %s", meta, err)
+		lastErr = err
+
+		s.mux.Lock()
+		s.metaIndex = (s.metaIndex + 1) % len(s.metas)
+		s.mux.Unlock()
+	}
+	return lastErr
+}
+
+func (s *configServices) fetch(meta string) ([]string, error) {
+	serviceUrl := fmt.Sprintf("%s/services/config?appId=%s", meta, url.QueryEscape(s.appId))
+	resp, err := s.client.Get(serviceUrl)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("服务器响应失败 -> %d - %s", resp.StatusCode, string(body))
+	}
+	var items []serviceDTO
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, item := range items {
+		if item.HomepageUrl != "" {
+			services = append(services, strings.TrimRight(item.HomepageUrl, "/"))
+		}
+	}
+	if len(services) == 0 {
+		return nil, errNoConfigService
+	}
+	return services, nil
+}
+
+// watch 定时刷新配置服务列表，直到 ctx 结束.
+func (s *configServices) watch(ctx context.Context, interval time.Duration) {
+	if len(s.metas) == 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.refresh(); err != nil {
+				s.log().Printf("刷新配置服务列表失败 -> %s", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package goapollo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConfigServices_Failover(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"20200101"}`))
+	}))
+	defer healthy.Close()
+
+	meta := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/config" || r.URL.Query().Get("appId") != "SampleApp" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]serviceDTO{
+			{AppName: "APOLLO-CONFIGSERVICE", InstanceId: "broken", HomepageUrl: broken.URL + "/"},
+			{AppName: "APOLLO-CONFIGSERVICE", InstanceId: "healthy", HomepageUrl: healthy.URL + "/"},
+		})
+	}))
+	defer meta.Close()
+
+	c := NewWithMetaServer("http://127.0.0.1:1,"+meta.URL, "SampleApp", "default")
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), "")
+
+	if _, err := c.sync("application"); err != nil {
+		t.Fatalf("同步配置失败 -> %s", err)
+	}
+	if val, ok := c.GetValue("timeout"); !ok || val != "100" {
+		t.Fatalf("配置值错误 -> %s", val)
+	}
+	if host, _ := c.services.current(); host != healthy.URL {
+		t.Fatalf("未切换到可用的配置服务 -> %s", host)
+	}
+}
+
+func TestConfigServices_RefreshWhenExhausted(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"20200101"}`))
+	}))
+	defer healthy.Close()
+
+	// 第一次只返回不可用的配置服务，之后返回可用的配置服务
+	var calls int32
+	meta := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := healthy.URL
+		if atomic.AddInt32(&calls, 1) == 1 {
+			host = broken.URL
+		}
+		_ = json.NewEncoder(w).Encode([]serviceDTO{{AppName: "APOLLO-CONFIGSERVICE", HomepageUrl: host}})
+	}))
+	defer meta.Close()
+
+	c := NewWithMetaServer(meta.URL, "SampleApp", "default")
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), "")
+
+	if _, err := c.sync("application"); err == nil {
+		t.Fatal("配置服务不可用时应返回错误")
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("配置服务都不可用时应重新获取配置服务列表 -> %d", calls)
+	}
+	if _, err := c.sync("application"); err != nil {
+		t.Fatalf("同步配置失败 -> %s", err)
+	}
+	if host, _ := c.services.current(); host != healthy.URL {
+		t.Fatalf("未使用新的配置服务列表 -> %s", host)
+	}
+}
+
+func TestConfigServices_ResetFailures(t *testing.T) {
+	var calls int32
+	meta := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_ = json.NewEncoder(w).Encode([]serviceDTO{
+			{AppName: "APOLLO-CONFIGSERVICE", HomepageUrl: "http://config1:8080"},
+			{AppName: "APOLLO-CONFIGSERVICE", HomepageUrl: "http://config2:8080"},
+		})
+	}))
+	defer meta.Close()
+
+	s := newMetaServices(meta.URL, "SampleApp")
+	if err := s.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	// 间隔出现的失败之间有成功的请求，不应累计触发刷新
+	for i := 0; i < 4; i++ {
+		host, _ := s.current()
+		s.failover(host)
+		host, _ = s.current()
+		s.succeed(host)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("请求成功后应重置失败次数 -> %d", n)
+	}
+	host, _ := s.current()
+	s.failover(host)
+	host, _ = s.current()
+	s.failover(host)
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("连续失败时应重新获取配置服务列表 -> %d", n)
+	}
+}
@@ -0,0 +1,240 @@
+package goapollo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// encryptedMagic 加密备份的文件头，之后依次为密钥 ID 长度、密钥 ID、nonce 和密文.
+const encryptedMagic = "GAENC1"
+
+// KeyProvider 提供加密备份文件使用的密钥，支持密钥轮换：使用当前密钥加密，根据密钥 ID 查找旧密钥解密.
+// 密钥长度为 16、24 或 32 字节，分别对应 AES-128、AES-192 和 AES-256.
+type KeyProvider interface {
+	// CurrentKey 获取当前用于加密的密钥及其 ID.
+	CurrentKey() (id string, key []byte, err error)
+	// Key 根据 ID 获取解密使用的密钥.
+	Key(id string) ([]byte, error)
+}
+
+// KeyRing 内存中的密钥集合，Current 为当前用于加密的密钥 ID.
+type KeyRing struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (r *KeyRing) CurrentKey() (string, []byte, error) {
+	key, err := r.Key(r.Current)
+	return r.Current, key, err
+}
+
+func (r *KeyRing) Key(id string) ([]byte, error) {
+	if key, ok := r.Keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("密钥不存在 -> %s", id)
+}
+
+// parseKeyRing 解析 "id:base64密钥" 格式的密钥列表，多个密钥用逗号或换行分隔，第一个为当前密钥.
+func parseKeyRing(s string) (*KeyRing, error) {
+	ring := &KeyRing{Keys: make(map[string][]byte)}
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.Index(item, ":")
+		if i <= 0 {
+			return nil, errors.New("密钥格式应为 id:base64密钥")
+		}
+		key, err := base64.StdEncoding.DecodeString(item[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("解码密钥失败 -> %s - %s", item[:i], err)
+		}
+		if ring.Current == "" {
+			ring.Current = item[:i]
+		}
+		ring.Keys[item[:i]] = key
+	}
+	if ring.Current == "" {
+		return nil, errors.New("未配置密钥")
+	}
+	return ring, nil
+}
+
+type envKeyProvider struct {
+	name string
+}
+
+// NewEnvKeyProvider 从环境变量读取密钥，格式为 "id:base64密钥"，多个密钥用逗号分隔，第一个为当前密钥.
+// 每次使用时重新读取环境变量.
+func NewEnvKeyProvider(name string) KeyProvider {
+	return &envKeyProvider{name: name}
+}
+
+func (p *envKeyProvider) ring() (*KeyRing, error) {
+	ring, err := parseKeyRing(os.Getenv(p.name))
+	if err != nil {
+		return nil, fmt.Errorf("读取环境变量中的密钥失败 -> %s - %s", p.name, err)
+	}
+	return ring, nil
+}
+
+func (p *envKeyProvider) CurrentKey() (string, []byte, error) {
+	ring, err := p.ring()
+	if err != nil {
+		return "", nil, err
+	}
+	return ring.CurrentKey()
+}
+
+func (p *envKeyProvider) Key(id string) ([]byte, error) {
+	ring, err := p.ring()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Key(id)
+}
+
+type fileKeyProvider struct {
+	path string
+}
+
+// NewFileKeyProvider 从文件读取密钥，每行一个 "id:base64密钥"，第一行为当前密钥.
+// 每次使用时重新读取文件，轮换密钥时在文件开头添加新密钥即可.
+func NewFileKeyProvider(path string) KeyProvider {
+	return &fileKeyProvider{path: path}
+}
+
+func (p *fileKeyProvider) ring() (*KeyRing, error) {
+	body, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := parseKeyRing(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败 -> %s - %s", p.path, err)
+	}
+	return ring, nil
+}
+
+func (p *fileKeyProvider) CurrentKey() (string, []byte, error) {
+	ring, err := p.ring()
+	if err != nil {
+		return "", nil, err
+	}
+	return ring.CurrentKey()
+}
+
+func (p *fileKeyProvider) Key(id string) ([]byte, error) {
+	ring, err := p.ring()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Key(id)
+}
+
+// KeyProviderFunc 使用回调函数提供密钥.
+type KeyProviderFunc struct {
+	Current func() (id string, key []byte, err error)
+	Lookup  func(id string) ([]byte, error)
+}
+
+func (f *KeyProviderFunc) CurrentKey() (string, []byte, error) {
+	return f.Current()
+}
+
+func (f *KeyProviderFunc) Key(id string) ([]byte, error) {
+	return f.Lookup(id)
+}
+
+// EncryptedSerializer 使用 AES-GCM 加密其他序列化器的输出，用于加密本地备份文件.
+type EncryptedSerializer struct {
+	serializer Serializer
+	keys       KeyProvider
+}
+
+// NewEncryptedSerializer 创建加密序列化器，serializer 为实际使用的序列化器.
+func NewEncryptedSerializer(serializer Serializer, keys KeyProvider) *EncryptedSerializer {
+	return &EncryptedSerializer{serializer: serializer, keys: keys}
+}
+
+func (e *EncryptedSerializer) Serialize(v *Configuration) ([]byte, error) {
+	body, err := e.serializer.Serialize(v)
+	if err != nil {
+		return nil, err
+	}
+	id, key, err := e.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) == 0 || len(id) > 255 {
+		return nil, fmt.Errorf("密钥 ID 长度应为 1 到 255 -> %s", id)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptedMagic)
+	buf.WriteByte(byte(len(id)))
+	buf.WriteString(id)
+	header := buf.Len()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	buf.Write(nonce)
+	return aead.Seal(buf.Bytes(), nonce, body, buf.Bytes()[:header]), nil
+}
+
+// Deserialize 解密带有加密文件头的内容，其他内容视为开启加密前写入的备份，直接交给内部的序列化器解析.
+func (e *EncryptedSerializer) Deserialize(body []byte, target *Configuration) error {
+	if !bytes.HasPrefix(body, []byte(encryptedMagic)) {
+		return e.serializer.Deserialize(body, target)
+	}
+	if len(body) <= len(encryptedMagic) {
+		return ErrCorruptBackup
+	}
+	header := len(encryptedMagic) + 1 + int(body[len(encryptedMagic)])
+	if len(body) < header {
+		return ErrCorruptBackup
+	}
+	id := string(body[len(encryptedMagic)+1 : header])
+	key, err := e.keys.Key(id)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	if len(body) < header+aead.NonceSize() {
+		return ErrCorruptBackup
+	}
+	nonce := body[header : header+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, body[header+aead.NonceSize():], body[:header])
+	if err != nil {
+		return fmt.Errorf("解密备份文件失败 -> %s - %s", id, err)
+	}
+	return e.serializer.Deserialize(plaintext, target)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,206 @@
+package goapollo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedSerializer_Rotation(t *testing.T) {
+	config := &Configuration{NamespaceName: "application", Configurations: map[string]string{"password": "secret"}, ReleaseKey: "r1"}
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 16)
+
+	for name, inner := range map[string]Serializer{"json": NewJsonSerializer(), "gob": NewGobSerializer()} {
+		ring := &KeyRing{Current: "k1", Keys: map[string][]byte{"k1": oldKey}}
+		serializer := NewEncryptedSerializer(inner, ring)
+		body, err := serializer.Serialize(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(body, []byte("secret")) {
+			t.Fatalf("%s: 加密后的内容包含明文", name)
+		}
+
+		// 轮换后使用新密钥写入，旧密钥仍可读取
+		ring.Current, ring.Keys["k2"] = "k2", newKey
+		var target Configuration
+		if err := serializer.Deserialize(body, &target); err != nil {
+			t.Fatalf("%s: 使用旧密钥解密失败 -> %s", name, err)
+		}
+		if target.Configurations["password"] != "secret" || target.ReleaseKey != "r1" {
+			t.Fatalf("%s: 解密结果不正确 -> %+v", name, target)
+		}
+		rotated, err := serializer.Serialize(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		delete(ring.Keys, "k1")
+		if err := serializer.Deserialize(body, &Configuration{}); err == nil {
+			t.Fatalf("%s: 旧密钥删除后应无法解密", name)
+		}
+		if err := serializer.Deserialize(rotated, &Configuration{}); err != nil {
+			t.Fatalf("%s: 使用新密钥解密失败 -> %s", name, err)
+		}
+	}
+}
+
+func TestEncryptedSerializer_Tampered(t *testing.T) {
+	ring := &KeyRing{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32), "k2": bytes.Repeat([]byte{1}, 32)}}
+	serializer := NewEncryptedSerializer(NewJsonSerializer(), ring)
+	body, err := serializer.Serialize(&Configuration{NamespaceName: "application", Configurations: map[string]string{"a": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-1] ^= 1
+	if err := serializer.Deserialize(tampered, &Configuration{}); err == nil {
+		t.Fatal("密文被修改时应返回错误")
+	}
+	// 密钥 ID 参与认证，即使两个密钥相同也不能替换
+	tampered = append([]byte(nil), body...)
+	tampered[len(encryptedMagic)+2] = '2'
+	if err := serializer.Deserialize(tampered, &Configuration{}); err == nil {
+		t.Fatal("密钥 ID 被修改时应返回错误")
+	}
+	if err := serializer.Deserialize(body[:len(encryptedMagic)+4], &Configuration{}); err != ErrCorruptBackup {
+		t.Fatalf("截断的内容应返回 ErrCorruptBackup -> %v", err)
+	}
+	if err := serializer.Deserialize([]byte(encryptedMagic), &Configuration{}); err != ErrCorruptBackup {
+		t.Fatalf("只有文件头的内容应返回 ErrCorruptBackup -> %v", err)
+	}
+}
+
+func TestKeyProvider(t *testing.T) {
+	k1, k2 := bytes.Repeat([]byte{1}, 16), bytes.Repeat([]byte{2}, 32)
+	spec := "k2:" + base64.StdEncoding.EncodeToString(k2) + ",k1:" + base64.StdEncoding.EncodeToString(k1)
+
+	_ = os.Setenv("GOAPOLLO_TEST_BACKUP_KEYS", spec)
+	defer os.Unsetenv("GOAPOLLO_TEST_BACKUP_KEYS")
+
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys")
+	if err := ioutil.WriteFile(path, []byte("k2:"+base64.StdEncoding.EncodeToString(k2)+"\n\nk1:"+base64.StdEncoding.EncodeToString(k1)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	providers := map[string]KeyProvider{
+		"env":  NewEnvKeyProvider("GOAPOLLO_TEST_BACKUP_KEYS"),
+		"file": NewFileKeyProvider(path),
+		"func": &KeyProviderFunc{
+			Current: func() (string, []byte, error) { return "k2", k2, nil },
+			Lookup:  (&KeyRing{Keys: map[string][]byte{"k1": k1, "k2": k2}}).Key,
+		},
+	}
+	for name, provider := range providers {
+		id, key, err := provider.CurrentKey()
+		if err != nil || id != "k2" || !bytes.Equal(key, k2) {
+			t.Fatalf("%s: 当前密钥不正确 -> %s - %v", name, id, err)
+		}
+		if key, err := provider.Key("k1"); err != nil || !bytes.Equal(key, k1) {
+			t.Fatalf("%s: 旧密钥不正确 -> %v", name, err)
+		}
+		if _, err := provider.Key("k3"); err == nil {
+			t.Fatalf("%s: 不存在的密钥应返回错误", name)
+		}
+	}
+
+	if _, _, err := NewEnvKeyProvider("GOAPOLLO_TEST_BACKUP_KEYS_MISSING").CurrentKey(); err == nil {
+		t.Fatal("未设置环境变量时应返回错误")
+	}
+}
+
+func TestClient_EncryptedBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ring := &KeyRing{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	serializer := NewEncryptedSerializer(NewJsonSerializer(), ring)
+	c, err := NewWithConfig(Config{Host: "http://127.0.0.1:1", AppId: "app", CacheDir: dir}, WithSerializer(serializer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddNamespace("application")
+	mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{"password": "secret"}})
+	if err := c.caches.dump("application"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "app", "application")
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(body, []byte("secret")) {
+		t.Fatal("备份文件包含明文")
+	}
+	config, err := ReadBackup(path, serializer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Configurations["password"] != "secret" {
+		t.Fatalf("读取加密备份失败 -> %+v", config)
+	}
+}
+
+func TestClient_EncryptedBackupFromPlaintext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 先使用未加密的序列化器写入备份，备份文件和上一个版本都是明文
+	conf := Config{Host: "http://127.0.0.1:1", AppId: "app", CacheDir: dir}
+	c, err := NewWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddNamespace("application")
+	for _, value := range []string{"v1", "v2"} {
+		mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{"password": value}})
+		if err := c.caches.dump("application"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ring := &KeyRing{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	serializer := NewEncryptedSerializer(NewJsonSerializer(), ring)
+	path := filepath.Join(dir, "app", "application")
+	for file, expected := range map[string]string{path: "v2", path + backupSuffix: "v1"} {
+		config, err := ReadBackup(file, serializer)
+		if err != nil || config.Configurations["password"] != expected {
+			t.Fatalf("开启加密后读取旧的备份失败 -> %s - %v", file, err)
+		}
+	}
+
+	c, err = NewWithConfig(conf, WithSerializer(serializer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddNamespace("application")
+	if val, _ := c.GetValue("password"); val != "v2" {
+		t.Fatalf("开启加密后读取旧的备份失败 -> %s", val)
+	}
+	if err := c.caches.dump("application"); err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(body, []byte(encryptedMagic)) {
+		t.Fatal("重新写入的备份文件未加密")
+	}
+}
@@ -0,0 +1,110 @@
+package goapollo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const contentKey = "content"
+
+// Format 命名空间的配置格式，由命名空间的后缀决定.
+type Format string
+
+const (
+	FormatProperties Format = "properties"
+	FormatYaml       Format = "yaml"
+	FormatYml        Format = "yml"
+	FormatJson       Format = "json"
+	FormatXml        Format = "xml"
+	FormatTxt        Format = "txt"
+)
+
+// NamespaceFormat 根据命名空间的后缀获取配置格式，例如 db.yaml 为 yaml，没有已知后缀时为 properties.
+func NamespaceFormat(namespace string) Format {
+	i := strings.LastIndex(namespace, ".")
+	if i < 0 {
+		return FormatProperties
+	}
+	switch format := Format(strings.ToLower(namespace[i+1:])); format {
+	case FormatProperties, FormatYaml, FormatYml, FormatJson, FormatXml, FormatTxt:
+		return format
+	}
+	return FormatProperties
+}
+
+// structured 判断格式是否可以展开为键值对.
+func (f Format) structured() bool {
+	return f == FormatYaml || f == FormatYml || f == FormatJson
+}
+
+// flatten 将 yaml 或 json 文档展开为使用点号连接的键值对，列表元素使用 key[0] 形式的键名.
+func flatten(format Format, content string) (map[string]string, error) {
+	var doc interface{}
+	switch format {
+	case FormatYaml, FormatYml:
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, err
+		}
+	case FormatJson:
+		decoder := json.NewDecoder(bytes.NewBufferString(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持展开的配置格式 -> %s", format)
+	}
+	values := make(map[string]string)
+	flattenValue("", doc, values)
+	return values, nil
+}
+
+func flattenValue(prefix string, value interface{}, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenValue(joinKey(prefix, key), item, values)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			flattenValue(joinKey(prefix, fmt.Sprint(key)), item, values)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(prefix+"["+strconv.Itoa(i)+"]", item, values)
+		}
+	case nil:
+		values[prefix] = ""
+	case string:
+		values[prefix] = v
+	case float64:
+		values[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// indexedValues 获取 key[0]、key[1] 形式的连续列表值，yaml 和 json 中的列表会展开为这种形式.
+func (c *Client) indexedValues(namespace, key string) ([]string, bool) {
+	var values []string
+	for i := 0; ; i++ {
+		val, ok := c.value(namespace, key+"["+strconv.Itoa(i)+"]")
+		if !ok {
+			break
+		}
+		values = append(values, val)
+	}
+	return values, len(values) > 0
+}
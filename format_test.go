@@ -0,0 +1,149 @@
+package goapollo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func mustStore(t *testing.T, c *namespaceCache, result result) *ChangeEvent {
+	event, err := c.store(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestNamespaceFormat(t *testing.T) {
+	cases := map[string]Format{
+		"application":     FormatProperties,
+		"db.yaml":         FormatYaml,
+		"db.YML":          FormatYml,
+		"routes.json":     FormatJson,
+		"layout.xml":      FormatXml,
+		"notice.txt":      FormatTxt,
+		"TEST1.public-ns": FormatProperties,
+	}
+	for namespace, expected := range cases {
+		if format := NamespaceFormat(namespace); format != expected {
+			t.Errorf("配置格式错误 -> %s - %s", namespace, format)
+		}
+	}
+}
+
+func TestClient_StructuredNamespace(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	content := "db:\n  host: 127.0.0.1\n  port: 3306\nservers:\n  - a.com\n  - b.com\ndebug: true\n"
+	mustStore(t, c.caches, result{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: content}})
+
+	if val, _ := c.GetValueWithNamespace("db.yaml", "db.host"); val != "127.0.0.1" {
+		t.Errorf("展开后的配置值错误 -> %s", val)
+	}
+	if val := c.GetIntWithNamespace("db.yaml", "db.port", 0); val != 3306 {
+		t.Errorf("展开后的配置值错误 -> %d", val)
+	}
+	if val := c.GetStringSliceWithNamespace("db.yaml", "servers", nil); !reflect.DeepEqual(val, []string{"a.com", "b.com"}) {
+		t.Errorf("展开后的列表错误 -> %v", val)
+	}
+	if val, _ := c.GetContentWithNamespace("db.yaml"); val != content {
+		t.Errorf("原始文档错误 -> %s", val)
+	}
+	keys := c.AllKeys("db.yaml")
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"db.host", "db.port", "debug", "servers[0]", "servers[1]"}) {
+		t.Errorf("键名错误 -> %v", keys)
+	}
+
+	var conf struct {
+		Servers []string `apollo:"servers"`
+	}
+	if err := c.Unmarshal("db.yaml", &conf); err != nil || !reflect.DeepEqual(conf.Servers, []string{"a.com", "b.com"}) {
+		t.Errorf("解析列表失败 -> %v - %v", conf.Servers, err)
+	}
+
+	event := mustStore(t, c.caches, result{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: "db:\n  host: 10.0.0.1\n  port: 3306\nservers:\n  - a.com\n"}})
+	if len(event.Changes) != 3 || event.Changes["db.host"].ChangeType != EventModify ||
+		event.Changes["servers[1]"].ChangeType != EventDelete || event.Changes["debug"].ChangeType != EventDelete {
+		t.Errorf("变更事件错误 -> %s", event)
+	}
+	if _, ok := c.GetValueWithNamespace("db.yaml", "debug"); ok {
+		t.Errorf("删除的配置项不应存在")
+	}
+	if config := c.caches.snapshot("db.yaml"); len(config.Configurations) != 1 || config.Configurations[contentKey] == "" {
+		t.Errorf("备份内容应为原始文档 -> %s", config)
+	}
+}
+
+func TestFlatten_Json(t *testing.T) {
+	values, err := flatten(FormatJson, `{"route":{"timeout":1.5,"retry":3,"hosts":["a","b"],"fallback":null}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"route.timeout":  "1.5",
+		"route.retry":    "3",
+		"route.hosts[0]": "a",
+		"route.hosts[1]": "b",
+		"route.fallback": "",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("展开结果错误 -> %v", values)
+	}
+}
+
+func TestClient_TextNamespace(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	mustStore(t, c.caches, result{NamespaceName: "layout.xml", Configurations: map[string]string{contentKey: "<layout/>"}})
+
+	if val, _ := c.GetContentWithNamespace("layout.xml"); val != "<layout/>" {
+		t.Errorf("原始文档错误 -> %s", val)
+	}
+}
+
+func TestClient_MalformedRelease(t *testing.T) {
+	// 同一个版本号第一次返回无法解析的文档，之后返回修正后的文档
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("releaseKey") == "r2" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		content := "db:\n  host: 10.0.0.1\n"
+		if atomic.AddInt32(&calls, 1) == 1 {
+			content = "db:\n  host: [10.0.0.1\n"
+		}
+		_ = json.NewEncoder(w).Encode(result{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: content}, ReleaseKey: "r2"})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "SampleApp", "default")
+	c.AddNamespaceWithSerializerWithPath("db.yaml", NewJsonSerializer(), "")
+	mustStore(t, c.caches, result{NamespaceName: "db.yaml", Configurations: map[string]string{contentKey: "db:\n  host: 127.0.0.1\n"}})
+	c.caches.setReleaseKey("db.yaml", "r1")
+
+	if _, err := c.sync("db.yaml"); err == nil {
+		t.Fatal("无法解析的文档应返回错误")
+	}
+	if key := c.GetReleaseKey("db.yaml"); key != "r1" {
+		t.Fatalf("解析失败时不应更新版本号 -> %s", key)
+	}
+	if val, _ := c.GetValueWithNamespace("db.yaml", "db.host"); val != "127.0.0.1" {
+		t.Fatalf("解析失败时不应修改当前配置 -> %s", val)
+	}
+
+	// 同一个通知的重试应拉取到修正后的文档
+	event, err := c.sync("db.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := event.Changes["db.host"]; change == nil || change.NewValue != "10.0.0.1" {
+		t.Fatalf("未使用修正后的文档 -> %s", event)
+	}
+	if key := c.GetReleaseKey("db.yaml"); key != "r2" {
+		t.Fatalf("版本号错误 -> %s", key)
+	}
+}
@@ -0,0 +1,344 @@
+package goapollo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultListSeparator = ","
+
+// ErrKeyNotExist 指定的配置项不存在.
+var ErrKeyNotExist = errors.New("配置项不存在")
+
+// ValueError 配置值无法转换为指定类型.
+type ValueError struct {
+	Namespace string
+	Key       string
+	Value     string
+	Type      string
+	Err       error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("配置值类型转换失败 -> [namespace=%s] - [key=%s] - [type=%s] - [error=%s]", e.Namespace, e.Key, e.Type, e.Err)
+}
+
+// SetListSeparator 设置 GetStringSlice 和 GetStringMap 使用的分隔符，默认为逗号.
+func (c *Client) SetListSeparator(separator string) {
+	c.listSeparator = separator
+}
+
+func (c *Client) lookup(namespace, key string) (string, error) {
+	val, ok := c.value(namespace, key)
+	if !ok {
+		return "", ErrKeyNotExist
+	}
+	return val, nil
+}
+
+func (c *Client) parseInt(val string) (int, error) {
+	return strconv.Atoi(strings.TrimSpace(val))
+}
+
+func (c *Client) parseInt64(val string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+}
+
+func (c *Client) parseBool(val string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(val))
+}
+
+func (c *Client) parseFloat64(val string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(val), 64)
+}
+
+// parseDuration 解析时间间隔，纯数字按毫秒处理.
+func (c *Client) parseDuration(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(val)
+}
+
+// parseStringSlice 解析列表，支持 JSON 数组和分隔符分隔的字符串.
+func (c *Client) parseStringSlice(val string) ([]string, error) {
+	val = strings.TrimSpace(val)
+	if strings.HasPrefix(val, "[") {
+		var items []string
+		err := json.Unmarshal([]byte(val), &items)
+		return items, err
+	}
+	items := make([]string, 0)
+	if val == "" {
+		return items, nil
+	}
+	for _, item := range strings.Split(val, c.separator()) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// parseStringMap 解析字典，支持 JSON 对象和 k1=v1,k2=v2 格式的字符串.
+func (c *Client) parseStringMap(val string) (map[string]string, error) {
+	val = strings.TrimSpace(val)
+	if strings.HasPrefix(val, "{") {
+		var items map[string]string
+		err := json.Unmarshal([]byte(val), &items)
+		return items, err
+	}
+	entries, err := c.parseStringSlice(val)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		i := strings.Index(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("无效的字典项 -> %s", entry)
+		}
+		items[strings.TrimSpace(entry[:i])] = strings.TrimSpace(entry[i+1:])
+	}
+	return items, nil
+}
+
+func (c *Client) separator() string {
+	if c.listSeparator == "" {
+		return defaultListSeparator
+	}
+	return c.listSeparator
+}
+
+// GetInt 获取默认命名空间的指定键值并转换为 int，不存在或解析失败时返回默认值.
+func (c *Client) GetInt(key string, defaultValue int) int {
+	return c.GetIntWithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetIntWithNamespace 获取指定命名空间的指定键值并转换为 int，不存在或解析失败时返回默认值.
+func (c *Client) GetIntWithNamespace(namespace, key string, defaultValue int) int {
+	if v, err := c.GetIntWithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetIntE 获取默认命名空间的指定键值并转换为 int.
+func (c *Client) GetIntE(key string) (int, error) {
+	return c.GetIntWithNamespaceE(defaultNamespace, key)
+}
+
+// GetIntWithNamespaceE 获取指定命名空间的指定键值并转换为 int，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetIntWithNamespaceE(namespace, key string) (int, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.parseInt(val)
+	if err != nil {
+		return 0, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "int", Err: err}
+	}
+	return v, nil
+}
+
+// GetInt64 获取默认命名空间的指定键值并转换为 int64，不存在或解析失败时返回默认值.
+func (c *Client) GetInt64(key string, defaultValue int64) int64 {
+	return c.GetInt64WithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetInt64WithNamespace 获取指定命名空间的指定键值并转换为 int64，不存在或解析失败时返回默认值.
+func (c *Client) GetInt64WithNamespace(namespace, key string, defaultValue int64) int64 {
+	if v, err := c.GetInt64WithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetInt64E 获取默认命名空间的指定键值并转换为 int64.
+func (c *Client) GetInt64E(key string) (int64, error) {
+	return c.GetInt64WithNamespaceE(defaultNamespace, key)
+}
+
+// GetInt64WithNamespaceE 获取指定命名空间的指定键值并转换为 int64，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetInt64WithNamespaceE(namespace, key string) (int64, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.parseInt64(val)
+	if err != nil {
+		return 0, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "int64", Err: err}
+	}
+	return v, nil
+}
+
+// GetBool 获取默认命名空间的指定键值并转换为 bool，不存在或解析失败时返回默认值.
+func (c *Client) GetBool(key string, defaultValue bool) bool {
+	return c.GetBoolWithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetBoolWithNamespace 获取指定命名空间的指定键值并转换为 bool，不存在或解析失败时返回默认值.
+func (c *Client) GetBoolWithNamespace(namespace, key string, defaultValue bool) bool {
+	if v, err := c.GetBoolWithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetBoolE 获取默认命名空间的指定键值并转换为 bool.
+func (c *Client) GetBoolE(key string) (bool, error) {
+	return c.GetBoolWithNamespaceE(defaultNamespace, key)
+}
+
+// GetBoolWithNamespaceE 获取指定命名空间的指定键值并转换为 bool，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetBoolWithNamespaceE(namespace, key string) (bool, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return false, err
+	}
+	v, err := c.parseBool(val)
+	if err != nil {
+		return false, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "bool", Err: err}
+	}
+	return v, nil
+}
+
+// GetFloat64 获取默认命名空间的指定键值并转换为 float64，不存在或解析失败时返回默认值.
+func (c *Client) GetFloat64(key string, defaultValue float64) float64 {
+	return c.GetFloat64WithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetFloat64WithNamespace 获取指定命名空间的指定键值并转换为 float64，不存在或解析失败时返回默认值.
+func (c *Client) GetFloat64WithNamespace(namespace, key string, defaultValue float64) float64 {
+	if v, err := c.GetFloat64WithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetFloat64E 获取默认命名空间的指定键值并转换为 float64.
+func (c *Client) GetFloat64E(key string) (float64, error) {
+	return c.GetFloat64WithNamespaceE(defaultNamespace, key)
+}
+
+// GetFloat64WithNamespaceE 获取指定命名空间的指定键值并转换为 float64，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetFloat64WithNamespaceE(namespace, key string) (float64, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.parseFloat64(val)
+	if err != nil {
+		return 0, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "float64", Err: err}
+	}
+	return v, nil
+}
+
+// GetDuration 获取默认命名空间的指定键值并转换为 time.Duration，不存在或解析失败时返回默认值.
+func (c *Client) GetDuration(key string, defaultValue time.Duration) time.Duration {
+	return c.GetDurationWithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetDurationWithNamespace 获取指定命名空间的指定键值并转换为 time.Duration，不存在或解析失败时返回默认值.
+func (c *Client) GetDurationWithNamespace(namespace, key string, defaultValue time.Duration) time.Duration {
+	if v, err := c.GetDurationWithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetDurationE 获取默认命名空间的指定键值并转换为 time.Duration.
+func (c *Client) GetDurationE(key string) (time.Duration, error) {
+	return c.GetDurationWithNamespaceE(defaultNamespace, key)
+}
+
+// GetDurationWithNamespaceE 获取指定命名空间的指定键值并转换为 time.Duration，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetDurationWithNamespaceE(namespace, key string) (time.Duration, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.parseDuration(val)
+	if err != nil {
+		return 0, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "time.Duration", Err: err}
+	}
+	return v, nil
+}
+
+// GetStringSlice 获取默认命名空间的指定键值并转换为 []string，不存在或解析失败时返回默认值.
+func (c *Client) GetStringSlice(key string, defaultValue []string) []string {
+	return c.GetStringSliceWithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetStringSliceWithNamespace 获取指定命名空间的指定键值并转换为 []string，不存在或解析失败时返回默认值.
+func (c *Client) GetStringSliceWithNamespace(namespace, key string, defaultValue []string) []string {
+	if v, err := c.GetStringSliceWithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetStringSliceE 获取默认命名空间的指定键值并转换为 []string.
+func (c *Client) GetStringSliceE(key string) ([]string, error) {
+	return c.GetStringSliceWithNamespaceE(defaultNamespace, key)
+}
+
+// GetStringSliceWithNamespaceE 获取指定命名空间的指定键值并转换为 []string，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetStringSliceWithNamespaceE(namespace, key string) ([]string, error) {
+	val, err := c.lookup(namespace, key)
+	if err == ErrKeyNotExist {
+		if items, ok := c.indexedValues(namespace, key); ok {
+			return items, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.parseStringSlice(val)
+	if err != nil {
+		return nil, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "[]string", Err: err}
+	}
+	return v, nil
+}
+
+// GetStringMap 获取默认命名空间的指定键值并转换为 map[string]string，不存在或解析失败时返回默认值.
+func (c *Client) GetStringMap(key string, defaultValue map[string]string) map[string]string {
+	return c.GetStringMapWithNamespace(defaultNamespace, key, defaultValue)
+}
+
+// GetStringMapWithNamespace 获取指定命名空间的指定键值并转换为 map[string]string，不存在或解析失败时返回默认值.
+func (c *Client) GetStringMapWithNamespace(namespace, key string, defaultValue map[string]string) map[string]string {
+	if v, err := c.GetStringMapWithNamespaceE(namespace, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// GetStringMapE 获取默认命名空间的指定键值并转换为 map[string]string.
+func (c *Client) GetStringMapE(key string) (map[string]string, error) {
+	return c.GetStringMapWithNamespaceE(defaultNamespace, key)
+}
+
+// GetStringMapWithNamespaceE 获取指定命名空间的指定键值并转换为 map[string]string，键不存在时返回 ErrKeyNotExist，解析失败时返回 *ValueError.
+func (c *Client) GetStringMapWithNamespaceE(namespace, key string) (map[string]string, error) {
+	val, err := c.lookup(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.parseStringMap(val)
+	if err != nil {
+		return nil, &ValueError{Namespace: namespace, Key: key, Value: val, Type: "map[string]string", Err: err}
+	}
+	return v, nil
+}
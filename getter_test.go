@@ -0,0 +1,68 @@
+package goapollo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_TypedGetters(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+	mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{
+		"port":     "8080",
+		"size":     "9223372036854775807",
+		"enabled":  "on",
+		"ratio":    "0.75",
+		"timeout":  "1m30s",
+		"interval": "500",
+		"hosts":    "a.com, b.com,,c.com",
+		"ports":    `["80","443"]`,
+		"labels":   "env=prod, zone = sh",
+		"invalid":  "abc",
+	}})
+
+	if v := c.GetInt("port", 0); v != 8080 {
+		t.Errorf("GetInt -> %d", v)
+	}
+	if v := c.GetInt64("size", 0); v != 9223372036854775807 {
+		t.Errorf("GetInt64 -> %d", v)
+	}
+	if v := c.GetBool("enabled", false); !v {
+		t.Errorf("GetBool -> %t", v)
+	}
+	if v := c.GetFloat64("ratio", 0); v != 0.75 {
+		t.Errorf("GetFloat64 -> %f", v)
+	}
+	if v := c.GetDuration("timeout", 0); v != 90*time.Second {
+		t.Errorf("GetDuration -> %s", v)
+	}
+	if v := c.GetDuration("interval", 0); v != 500*time.Millisecond {
+		t.Errorf("GetDuration -> %s", v)
+	}
+	if v := c.GetStringSlice("hosts", nil); !reflect.DeepEqual(v, []string{"a.com", "b.com", "c.com"}) {
+		t.Errorf("GetStringSlice -> %v", v)
+	}
+	if v := c.GetStringSlice("ports", nil); !reflect.DeepEqual(v, []string{"80", "443"}) {
+		t.Errorf("GetStringSlice -> %v", v)
+	}
+	if v := c.GetStringMap("labels", nil); !reflect.DeepEqual(v, map[string]string{"env": "prod", "zone": "sh"}) {
+		t.Errorf("GetStringMap -> %v", v)
+	}
+	if v := c.GetInt("invalid", 10); v != 10 {
+		t.Errorf("GetInt 默认值 -> %d", v)
+	}
+	if _, err := c.GetIntE("invalid"); err == nil {
+		t.Errorf("GetIntE 应返回解析错误")
+	} else if _, ok := err.(*ValueError); !ok {
+		t.Errorf("GetIntE 错误类型 -> %T", err)
+	}
+	if _, err := c.GetBoolWithNamespaceE(defaultNamespace, "missing"); err != ErrKeyNotExist {
+		t.Errorf("GetBoolWithNamespaceE -> %v", err)
+	}
+
+	c.SetListSeparator(";")
+	mustStore(t, c.caches, result{NamespaceName: "wechat", Configurations: map[string]string{"hosts": "a.com;b.com"}})
+	if v := c.GetStringSliceWithNamespace("wechat", "hosts", nil); !reflect.DeepEqual(v, []string{"a.com", "b.com"}) {
+		t.Errorf("GetStringSliceWithNamespace -> %v", v)
+	}
+}
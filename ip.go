@@ -0,0 +1,98 @@
+package goapollo
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// interfaceAddr 网卡及其地址.
+type interfaceAddr struct {
+	name string
+	ip   net.IP
+}
+
+// localAddrs 获取已启用网卡上的非回环、非链路本地地址.
+func localAddrs() []interfaceAddr {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var addrs []interfaceAddr
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		items, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			ipNet, ok := item.(*net.IPNet)
+			if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() || ipNet.IP.IsUnspecified() {
+				continue
+			}
+			addrs = append(addrs, interfaceAddr{name: iface.Name, ip: ipNet.IP})
+		}
+	}
+	return addrs
+}
+
+// selectIP 按偏好顺序选择客户端 IP，偏好可以是网卡名称或 CIDR，同一偏好下优先使用 IPv4.
+// 没有匹配的偏好时使用第一个 IPv4 地址，没有 IPv4 地址时使用第一个 IPv6 地址.
+func selectIP(addrs []interfaceAddr, preferences []string) string {
+	first := func(match func(addr interfaceAddr) bool) string {
+		var v6 string
+		for _, addr := range addrs {
+			if !match(addr) {
+				continue
+			}
+			if addr.ip.To4() != nil {
+				return addr.ip.String()
+			}
+			if v6 == "" {
+				v6 = addr.ip.String()
+			}
+		}
+		return v6
+	}
+	for _, preference := range preferences {
+		var match func(addr interfaceAddr) bool
+		if _, ipNet, err := net.ParseCIDR(preference); err == nil {
+			match = func(addr interfaceAddr) bool { return ipNet.Contains(addr.ip) }
+		} else {
+			match = func(addr interfaceAddr) bool { return addr.name == preference }
+		}
+		if ip := first(match); ip != "" {
+			return ip
+		}
+	}
+	return first(func(interfaceAddr) bool { return true })
+}
+
+// validateIPPreferences 校验 IP 偏好，包含 / 的偏好必须是有效的 CIDR.
+func validateIPPreferences(preferences []string) error {
+	for _, preference := range preferences {
+		if strings.Contains(preference, "/") {
+			if _, _, err := net.ParseCIDR(preference); err != nil {
+				return fmt.Errorf("无效的 IP 偏好 -> %s", preference)
+			}
+		}
+	}
+	return nil
+}
+
+// joinLabels 去除空白和重复的标签，使用逗号连接，每个参数也可以是逗号分隔的多个标签.
+func joinLabels(labels ...string) string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		for _, item := range strings.Split(label, ",") {
+			if item = strings.TrimSpace(item); item != "" && !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+	return strings.Join(items, ",")
+}
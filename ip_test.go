@@ -0,0 +1,166 @@
+package goapollo
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lifei6671/goapollo/apollotest"
+)
+
+func TestSelectIP(t *testing.T) {
+	addrs := []interfaceAddr{
+		{name: "docker0", ip: net.ParseIP("172.17.0.1")},
+		{name: "eth0", ip: net.ParseIP("fd00::10")},
+		{name: "eth0", ip: net.ParseIP("10.0.0.10")},
+		{name: "eth1", ip: net.ParseIP("192.168.1.10")},
+	}
+	cases := []struct {
+		preferences []string
+		expected    string
+	}{
+		{nil, "172.17.0.1"},
+		{[]string{"eth0"}, "10.0.0.10"},
+		{[]string{"192.168.0.0/16", "eth0"}, "192.168.1.10"},
+		{[]string{"eth9", "fd00::/8"}, "fd00::10"},
+		{[]string{"10.1.0.0/16"}, "172.17.0.1"},
+	}
+	for _, item := range cases {
+		if ip := selectIP(addrs, item.preferences); ip != item.expected {
+			t.Errorf("选择的 IP 错误 -> %v - %s", item.preferences, ip)
+		}
+	}
+	if ip := selectIP([]interfaceAddr{{name: "eth0", ip: net.ParseIP("fd00::10")}}, nil); ip != "fd00::10" {
+		t.Errorf("没有 IPv4 地址时应使用 IPv6 地址 -> %s", ip)
+	}
+	if ip := selectIP(nil, []string{"eth0"}); ip != "" {
+		t.Errorf("没有可用地址时应返回空 -> %s", ip)
+	}
+	for _, addr := range localAddrs() {
+		if addr.ip.IsLoopback() {
+			t.Errorf("不应选择回环地址 -> %s", addr.ip)
+		}
+	}
+
+	if _, err := NewWithConfig(Config{Host: "http://localhost", AppId: "SampleApp"}, WithIPPreference("10.0.0.0/33")); err == nil {
+		t.Error("无效的 CIDR 应返回校验错误")
+	}
+}
+
+func TestJoinLabels(t *testing.T) {
+	if label := joinLabels("canary, beta", "", "beta", " gray "); label != "canary,beta,gray" {
+		t.Fatalf("标签错误 -> %s", label)
+	}
+}
+
+func TestClient_GrayRule(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
+
+	// 记录带有 ip 和 label 参数的配置和通知请求
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	mux := &sync.Mutex{}
+	labeled := make(map[string]bool)
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/configs/") {
+			path = "/configs/"
+		}
+		query := r.URL.Query()
+		if _, ok := query["ip"]; ok && query.Get("label") == "beta,canary" {
+			mux.Lock()
+			labeled[path] = true
+			mux.Unlock()
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer recorder.Close()
+
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := Config{Host: recorder.URL, AppId: "SampleApp", CacheDir: dir, Namespaces: []string{"application"}}
+	canary, err := NewWithConfig(conf, WithLabel("beta", "canary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addressed, err := NewWithConfig(conf, WithClientIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	normal, err := NewWithConfig(conf, WithClientIP("10.1.2.4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*Client{canary, addressed, normal} {
+		if err := c.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		waitChangeEvent(t, c)
+	}
+	if canary.ip != selectIP(localAddrs(), nil) {
+		t.Fatalf("未设置 IP 时应自动选择本机地址 -> %s", canary.ip)
+	}
+
+	server.GrayRelease("SampleApp", "default", "application", apollotest.GrayRule{Labels: []string{"canary"}}, map[string]string{"timeout": "200"})
+	if change := waitChangeEvent(t, canary).Changes["timeout"]; change == nil || change.NewValue != "200" {
+		t.Fatalf("命中标签的客户端未使用灰度配置 -> %+v", change)
+	}
+	server.GrayRelease("SampleApp", "default", "application", apollotest.GrayRule{IPs: []string{"10.1.2.3"}}, map[string]string{"timeout": "300"})
+	if change := waitChangeEvent(t, addressed).Changes["timeout"]; change == nil || change.NewValue != "300" {
+		t.Fatalf("命中 IP 的客户端未使用灰度配置 -> %+v", change)
+	}
+	if change := waitChangeEvent(t, canary).Changes["timeout"]; change == nil || change.NewValue != "100" {
+		t.Fatalf("灰度规则修改后未恢复主版本配置 -> %+v", change)
+	}
+	select {
+	case event := <-normal.WatchUpdate():
+		t.Fatalf("未命中灰度规则的客户端不应收到事件 -> %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	for _, path := range []string{"/configs/", "/notifications/v2"} {
+		if !labeled[path] {
+			t.Errorf("请求缺少 ip 或 label 参数 -> %s", path)
+		}
+	}
+}
+
+func TestClient_ClientQuery(t *testing.T) {
+	c, err := NewWithConfig(Config{Host: "http://localhost", AppId: "SampleApp"}, WithClientIP("10.0.0.1"), WithLabel("canary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := c.notification.(*notificationRepo)
+	if repo.ip != "10.0.0.1" || repo.label != "canary" {
+		t.Fatalf("通知请求的 ip 和 label 错误 -> %s - %s", repo.ip, repo.label)
+	}
+	c.SetClientIp("10.0.0.2")
+	c.SetLabel("beta", "gray")
+	if c.label != "beta,gray" || repo.ip != "10.0.0.2" || repo.label != "beta,gray" {
+		t.Fatalf("修改 ip 和 label 后通知请求未更新 -> %s - %s", repo.ip, repo.label)
+	}
+	if query := clientQuery("10.0.0.2", "beta,gray"); query != "&ip=10.0.0.2&label=beta%2Cgray" {
+		t.Fatalf("请求参数错误 -> %s", query)
+	}
+}
@@ -0,0 +1,95 @@
+package goapollo
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ChangeListener 配置项变更回调.
+type ChangeListener func(key string, change *Change)
+
+type changeListener struct {
+	id        uint64
+	namespace string
+	pattern   string
+	fn        ChangeListener
+}
+
+// match 判断配置项是否匹配订阅规则，规则以 * 结尾时按前缀匹配，为空时匹配所有配置项.
+func (l *changeListener) match(namespace, key string) bool {
+	if l.namespace != namespace {
+		return false
+	}
+	if l.pattern == "" || l.pattern == "*" {
+		return true
+	}
+	if strings.HasSuffix(l.pattern, "*") {
+		return strings.HasPrefix(key, strings.TrimSuffix(l.pattern, "*"))
+	}
+	return l.pattern == key
+}
+
+// call 调用回调，回调中的异常不会影响其他订阅者.
+func (l *changeListener) call(key string, change *Change, logger ILogger) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Printf("变更回调出现未处理异常 -> %s - %s - %s", l.namespace, key, err)
+		}
+	}()
+	l.fn(key, change)
+}
+
+// OnChange 订阅指定命名空间的配置项变更，keyOrPrefix 以 * 结尾时按前缀匹配，例如 db.*，为空时订阅所有配置项.
+// 每个订阅者都会收到匹配的变更，返回的函数用于取消订阅.
+func (c *Client) OnChange(namespace, keyOrPrefix string, fn ChangeListener) (unsubscribe func()) {
+	l := &changeListener{
+		id:        atomic.AddUint64(&c.listenerId, 1),
+		namespace: namespace,
+		pattern:   keyOrPrefix,
+		fn:        fn,
+	}
+	c.rmx.Lock()
+	c.listeners = append(c.listeners, l)
+	c.rmx.Unlock()
+
+	once := &sync.Once{}
+	return func() {
+		once.Do(func() {
+			c.rmx.Lock()
+			defer c.rmx.Unlock()
+			for i, item := range c.listeners {
+				if item.id == l.id {
+					c.listeners = append(c.listeners[:i:i], c.listeners[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// notifyListeners 将变更事件分发给所有匹配的订阅者.
+func (c *Client) notifyListeners(event *ChangeEvent) {
+	c.rmx.RLock()
+	listeners := make([]*changeListener, len(c.listeners))
+	copy(listeners, c.listeners)
+	c.rmx.RUnlock()
+
+	if len(listeners) == 0 || len(event.Changes) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(event.Changes))
+	for key := range event.Changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, l := range listeners {
+			if l.match(event.Namespace, key) {
+				l.call(key, event.Changes[key], c.log())
+			}
+		}
+	}
+}
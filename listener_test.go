@@ -0,0 +1,53 @@
+package goapollo
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestClient_OnChange(t *testing.T) {
+	c := New("http://localhost", "SampleApp", "default")
+
+	var exact, prefix, all []string
+	c.OnChange(defaultNamespace, "timeout", func(key string, change *Change) {
+		exact = append(exact, key+"="+change.NewValue)
+	})
+	c.OnChange(defaultNamespace, "db.*", func(key string, change *Change) {
+		panic("listener panic")
+	})
+	unsubscribe := c.OnChange(defaultNamespace, "db.*", func(key string, change *Change) {
+		prefix = append(prefix, key)
+	})
+	c.OnChange(defaultNamespace, "", func(key string, change *Change) {
+		all = append(all, key)
+	})
+	c.OnChange("wechat", "", func(key string, change *Change) {
+		t.Errorf("不应收到其他命名空间的变更 -> %s", key)
+	})
+
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{
+		"timeout": "100",
+		"db.host": "127.0.0.1",
+		"db.port": "3306",
+		"name":    "sample",
+	}}))
+
+	if !reflect.DeepEqual(exact, []string{"timeout=100"}) {
+		t.Errorf("按键订阅结果错误 -> %v", exact)
+	}
+	sort.Strings(prefix)
+	if !reflect.DeepEqual(prefix, []string{"db.host", "db.port"}) {
+		t.Errorf("按前缀订阅结果错误 -> %v", prefix)
+	}
+	if len(all) != 4 {
+		t.Errorf("订阅所有配置项结果错误 -> %v", all)
+	}
+
+	unsubscribe()
+	prefix = nil
+	c.publish(mustStore(t, c.caches, result{NamespaceName: defaultNamespace, Configurations: map[string]string{"db.host": "10.0.0.1"}}))
+	if len(prefix) != 0 {
+		t.Errorf("取消订阅后不应收到变更 -> %v", prefix)
+	}
+}
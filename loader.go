@@ -0,0 +1,251 @@
+package goapollo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultConfigFiles 未指定配置文件时在当前目录依次查找的文件.
+var defaultConfigFiles = []string{"apollo.yaml", "apollo.yml", "apollo.json"}
+
+// LoadConfig 从配置文件和环境变量加载客户端配置，优先级为 opts > 环境变量 > 配置文件 > 默认值.
+//
+// file 为空时使用 APOLLO_CONFIG_FILE 环境变量指定的文件，未设置时依次查找当前目录下的
+// apollo.yaml、apollo.yml 和 apollo.json，都不存在时只读取环境变量. 支持的环境变量：
+//
+//	APOLLO_CONFIG_SERVICE, APOLLO_HOST  配置服务地址
+//	APOLLO_META                         Meta Server 地址
+//	APOLLO_APP_ID, APP_ID               AppId
+//	APOLLO_CLUSTER, IDC                 集群，未设置 APOLLO_CLUSTER 时使用 IDC
+//	APOLLO_NAMESPACE                    命名空间，多个用分号分隔
+//	APOLLO_ACCESS_KEY_SECRET            访问密钥
+//	APOLLO_CACHE_DIR                    本地备份目录
+//	APOLLO_LABEL                        客户端标签，多个用逗号分隔
+//	APOLLO_IP_PREFERENCE                自动选择客户端 IP 时优先使用的网卡或 CIDR，多个用逗号分隔
+//	APOLLO_OFFLINE                      为 true 时开启离线模式
+func LoadConfig(file string, opts ...Option) (Config, error) {
+	return loadConfig(file, os.Getenv, opts...)
+}
+
+func loadConfig(file string, getenv func(string) string, opts ...Option) (Config, error) {
+	var conf Config
+
+	if file == "" {
+		file = getenv("APOLLO_CONFIG_FILE")
+	}
+	if file == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				file = name
+				break
+			}
+		}
+	}
+	if file != "" {
+		if err := loadConfigFile(file, &conf); err != nil {
+			return conf, err
+		}
+	}
+	loadConfigEnv(getenv, &conf)
+
+	for _, opt := range opts {
+		if err := opt(&conf); err != nil {
+			return conf, err
+		}
+	}
+	return conf, nil
+}
+
+// fileDuration 配置文件中的时间间隔，支持 30s 形式的字符串，纯数字按毫秒处理.
+type fileDuration time.Duration
+
+func (d *fileDuration) set(s string) error {
+	s = strings.TrimSpace(s)
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*d = fileDuration(time.Duration(ms) * time.Millisecond)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = fileDuration(v)
+	return nil
+}
+
+func (d *fileDuration) UnmarshalJSON(body []byte) error {
+	var s string
+	if err := json.Unmarshal(body, &s); err != nil {
+		s = string(body)
+	}
+	return d.set(s)
+}
+
+func (d *fileDuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.set(s)
+}
+
+// fileConfig 配置文件的结构，键名与 Config 的 json 标签一致.
+type fileConfig struct {
+	Host            string       `json:"host" yaml:"host"`
+	MetaServer      string       `json:"meta_server" yaml:"meta_server"`
+	AppId           string       `json:"app_id" yaml:"app_id"`
+	Cluster         string       `json:"cluster" yaml:"cluster"`
+	Namespaces      []string     `json:"namespaces" yaml:"namespaces"`
+	IP              string       `json:"ip" yaml:"ip"`
+	IPPreference    []string     `json:"ip_preference" yaml:"ip_preference"`
+	Label           string       `json:"label" yaml:"label"`
+	Secret          string       `json:"secret" yaml:"secret"`
+	CacheDir        string       `json:"cache_dir" yaml:"cache_dir"`
+	Timeout         fileDuration `json:"timeout" yaml:"timeout"`
+	LongPollTimeout fileDuration `json:"long_poll_timeout" yaml:"long_poll_timeout"`
+	EventBufferSize int          `json:"event_buffer_size" yaml:"event_buffer_size"`
+	RefreshInterval fileDuration `json:"refresh_interval" yaml:"refresh_interval"`
+	ReadyPolicy     string       `json:"ready_policy" yaml:"ready_policy"`
+	ReadyTimeout    fileDuration `json:"ready_timeout" yaml:"ready_timeout"`
+	DeliveryMode    string       `json:"delivery_mode" yaml:"delivery_mode"`
+	Offline         bool         `json:"offline" yaml:"offline"`
+	WatchInterval   fileDuration `json:"watch_interval" yaml:"watch_interval"`
+}
+
+// loadConfigFile 读取 json 或 yaml 格式的配置文件，出现未知的配置项时返回错误.
+func loadConfigFile(file string, conf *Config) error {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败 -> %s", err)
+	}
+	var fc fileConfig
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewBuffer(body))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&fc)
+	default:
+		err = yaml.UnmarshalStrict(body, &fc)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件失败 -> %s - %s", file, err)
+	}
+
+	readyPolicy, err := parseReadyPolicy(fc.ReadyPolicy)
+	if err != nil {
+		return err
+	}
+	deliveryMode, err := parseDeliveryMode(fc.DeliveryMode)
+	if err != nil {
+		return err
+	}
+	*conf = Config{
+		Host:            fc.Host,
+		MetaServer:      fc.MetaServer,
+		AppId:           fc.AppId,
+		Cluster:         fc.Cluster,
+		Namespaces:      fc.Namespaces,
+		IP:              fc.IP,
+		IPPreference:    fc.IPPreference,
+		Label:           fc.Label,
+		Secret:          fc.Secret,
+		CacheDir:        fc.CacheDir,
+		Timeout:         time.Duration(fc.Timeout),
+		LongPollTimeout: time.Duration(fc.LongPollTimeout),
+		EventBufferSize: fc.EventBufferSize,
+		RefreshInterval: time.Duration(fc.RefreshInterval),
+		ReadyPolicy:     readyPolicy,
+		ReadyTimeout:    time.Duration(fc.ReadyTimeout),
+		DeliveryMode:    deliveryMode,
+		Offline:         fc.Offline,
+		WatchInterval:   time.Duration(fc.WatchInterval),
+	}
+	return nil
+}
+
+// loadConfigEnv 使用环境变量覆盖配置，设置了配置服务地址时忽略更低优先级的 Meta Server，反之亦然.
+func loadConfigEnv(getenv func(string) string, conf *Config) {
+	if host := firstEnv(getenv, "APOLLO_CONFIG_SERVICE", "APOLLO_HOST"); host != "" {
+		conf.Host = host
+		conf.MetaServer = ""
+	} else if meta := getenv("APOLLO_META"); meta != "" {
+		conf.MetaServer = meta
+		conf.Host = ""
+	}
+	if appId := firstEnv(getenv, "APOLLO_APP_ID", "APP_ID"); appId != "" {
+		conf.AppId = appId
+	}
+	if cluster := firstEnv(getenv, "APOLLO_CLUSTER", "IDC"); cluster != "" {
+		conf.Cluster = cluster
+	}
+	if namespace := getenv("APOLLO_NAMESPACE"); namespace != "" {
+		conf.Namespaces = nil
+		for _, name := range strings.Split(namespace, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				conf.Namespaces = append(conf.Namespaces, name)
+			}
+		}
+	}
+	if secret := getenv("APOLLO_ACCESS_KEY_SECRET"); secret != "" {
+		conf.Secret = secret
+	}
+	if dir := getenv("APOLLO_CACHE_DIR"); dir != "" {
+		conf.CacheDir = dir
+	}
+	if label := getenv("APOLLO_LABEL"); label != "" {
+		conf.Label = label
+	}
+	if preference := getenv("APOLLO_IP_PREFERENCE"); preference != "" {
+		conf.IPPreference = nil
+		for _, item := range strings.Split(preference, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				conf.IPPreference = append(conf.IPPreference, item)
+			}
+		}
+	}
+	if offline, err := strconv.ParseBool(getenv("APOLLO_OFFLINE")); err == nil {
+		conf.Offline = offline
+	}
+}
+
+func firstEnv(getenv func(string) string, keys ...string) string {
+	for _, key := range keys {
+		if v := getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func parseReadyPolicy(s string) (ReadyPolicy, error) {
+	for _, policy := range []ReadyPolicy{ReadyPolicyCache, ReadyPolicyRemote, ReadyPolicyNone} {
+		if strings.EqualFold(s, policy.String()) {
+			return policy, nil
+		}
+	}
+	if s == "" {
+		return ReadyPolicyCache, nil
+	}
+	return ReadyPolicyCache, fmt.Errorf("未知的 ReadyPolicy -> %s", s)
+}
+
+func parseDeliveryMode(s string) (DeliveryMode, error) {
+	for _, mode := range []DeliveryMode{DeliveryDrop, DeliveryBlock, DeliveryCoalesce} {
+		if strings.EqualFold(s, mode.String()) {
+			return mode, nil
+		}
+	}
+	if s == "" {
+		return DeliveryDrop, nil
+	}
+	return DeliveryDrop, fmt.Errorf("未知的 DeliveryMode -> %s", s)
+}
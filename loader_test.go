@@ -0,0 +1,132 @@
+package goapollo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "apollo.yaml")
+	body := `meta_server: http://meta:8080
+app_id: SampleApp
+cluster: default
+namespaces:
+  - application
+  - db.yaml
+label: file
+ip_preference:
+  - eth0
+cache_dir: /data/file
+timeout: 3000
+refresh_interval: 1m
+ready_policy: remote
+delivery_mode: coalesce
+`
+	if err := ioutil.WriteFile(file, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"APOLLO_CONFIG_SERVICE": "http://config:8080",
+		"IDC":                   "SHAJQ",
+		"APOLLO_LABEL":          "env",
+		"APOLLO_CACHE_DIR":      "/data/env",
+		"APOLLO_IP_PREFERENCE":  "10.0.0.0/8, eth1",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	conf, err := loadConfig(file, getenv, WithLabel("option"))
+	if err != nil {
+		t.Fatalf("加载配置失败 -> %s", err)
+	}
+	expected := Config{
+		Host:            "http://config:8080",
+		AppId:           "SampleApp",
+		Cluster:         "SHAJQ",
+		Namespaces:      []string{"application", "db.yaml"},
+		IPPreference:    []string{"10.0.0.0/8", "eth1"},
+		Label:           "option",
+		CacheDir:        "/data/env",
+		Timeout:         3 * time.Second,
+		RefreshInterval: time.Minute,
+		ReadyPolicy:     ReadyPolicyRemote,
+		DeliveryMode:    DeliveryCoalesce,
+	}
+	if !reflect.DeepEqual(conf, expected) {
+		t.Fatalf("配置优先级错误 -> %+v", conf)
+	}
+	if _, err := NewWithConfig(conf); err != nil {
+		t.Fatalf("加载的配置无效 -> %s", err)
+	}
+
+	env = map[string]string{"APOLLO_CLUSTER": "default", "IDC": "SHAJQ", "APOLLO_NAMESPACE": "a;b"}
+	if conf, err = loadConfig(file, getenv); err != nil {
+		t.Fatal(err)
+	}
+	if conf.MetaServer != "http://meta:8080" || conf.Cluster != "default" || !reflect.DeepEqual(conf.Namespaces, []string{"a", "b"}) {
+		t.Fatalf("环境变量覆盖错误 -> %+v", conf)
+	}
+}
+
+func TestLoadConfig_ServerOptions(t *testing.T) {
+	// 选项设置的地址优先于环境变量中另一种地址
+	cases := []struct {
+		env        map[string]string
+		opt        Option
+		host       string
+		metaServer string
+	}{
+		{map[string]string{"APOLLO_META": "http://meta:8080"}, WithHost("http://config:8080"), "http://config:8080", ""},
+		{map[string]string{"APOLLO_CONFIG_SERVICE": "http://config:8080"}, WithMetaServer("http://meta:8080"), "", "http://meta:8080"},
+	}
+	for _, item := range cases {
+		env := item.env
+		env["APOLLO_APP_ID"] = "SampleApp"
+		conf, err := loadConfig("", func(key string) string { return env[key] }, item.opt)
+		if err != nil {
+			t.Fatalf("加载配置失败 -> %s", err)
+		}
+		if conf.Host != item.host || conf.MetaServer != item.metaServer {
+			t.Fatalf("选项未覆盖环境变量 -> %s - %s", conf.Host, conf.MetaServer)
+		}
+		if _, err := NewWithConfig(conf); err != nil {
+			t.Fatalf("加载的配置无效 -> %s", err)
+		}
+	}
+}
+
+func TestLoadConfig_InvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"unknown.json": `{"host":"http://config:8080","app_id":"SampleApp","hots":"typo"}`,
+		"unknown.yaml": "host: http://config:8080\nappid: SampleApp\n",
+		"policy.yaml":  "ready_policy: always\n",
+	}
+	getenv := func(string) string { return "" }
+	for name, body := range files {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(file, getenv); err == nil {
+			t.Errorf("%s 应返回错误", name)
+		}
+	}
+	if _, err := loadConfig(filepath.Join(dir, "missing.yaml"), getenv); err == nil {
+		t.Errorf("配置文件不存在时应返回错误")
+	}
+}
@@ -25,3 +25,11 @@ func NewLocalLogger() ILogger {
 func SetILogger(logger1 ILogger) {
 	logger = logger1
 }
+
+// loggerOrDefault 未单独设置日志接口时使用全局日志.
+func loggerOrDefault(l ILogger) ILogger {
+	if l != nil {
+		return l
+	}
+	return logger
+}
@@ -13,6 +13,9 @@ import (
 	"time"
 )
 
+// pollBackoffKey 长轮询失败时使用的退避状态.
+const pollBackoffKey = "notifications"
+
 type Notification struct {
 	NamespaceName  string `json:"namespaceName,omitempty"`
 	NotificationId int    `json:"notificationId,omitempty"`
@@ -37,15 +40,22 @@ type INotification interface {
 }
 
 type notificationRepo struct {
-	notifications   *sync.Map
-	notificationCh  chan *Notification
-	client          *http.Client
-	notificationUrl string
-	cancel          context.CancelFunc
-	once            *sync.Once
+	notifications  *sync.Map
+	notificationCh chan *Notification
+	client         *http.Client
+	services       *configServices
+	appId          string
+	cluster        string
+	secret         string
+	ip             string
+	label          string
+	backoff        *backoffState
+	logger         ILogger
+	cancel         context.CancelFunc
+	once           *sync.Once
 }
 
-func newNotificationRepo(host, appId, cluster string) *notificationRepo {
+func newNotificationRepo(services *configServices, appId, cluster string) *notificationRepo {
 
 	var netTransport = &http.Transport{
 		DialContext: (&net.Dialer{
@@ -57,24 +67,36 @@ func newNotificationRepo(host, appId, cluster string) *notificationRepo {
 		TLSHandshakeTimeout: 100 * time.Second, //TLS安全连接握手超时时间
 	}
 
-	notificationUrl := fmt.Sprintf("%s/notifications/v2?appId=%s&cluster=%s&notifications=", host, appId, cluster)
-
 	return &notificationRepo{
-		notifications:   &sync.Map{},
-		notificationUrl: notificationUrl,
+		notifications: &sync.Map{},
+		services:      services,
+		appId:         appId,
+		cluster:       cluster,
 		client: &http.Client{
 			Timeout:   time.Second * 90,
 			Transport: netTransport,
 		},
 		notificationCh: make(chan *Notification, 10),
+		backoff:        newBackoffState(DefaultBackoff()),
 		once:           &sync.Once{},
 	}
 }
 
+func (n *notificationRepo) log() ILogger {
+	return loggerOrDefault(n.logger)
+}
+
 func (n *notificationRepo) AddNamespace(namespace string) {
 	n.notifications.Store(namespace, defaultNotificationId)
 }
 
+// setNotificationId 设置已添加命名空间的通知 ID，长轮询将从该 ID 开始.
+func (n *notificationRepo) setNotificationId(namespace string, id int) {
+	if _, ok := n.notifications.Load(namespace); ok {
+		n.notifications.Store(namespace, id)
+	}
+}
+
 func (n *notificationRepo) DeleteNamespace(namespace string) {
 	n.notifications.Delete(namespace)
 }
@@ -89,56 +111,19 @@ func (n *notificationRepo) Watch() <-chan *Notification {
 				case <-ctx.Done():
 					return
 				default:
-					{
-						notificationUrl := n.notificationUrl + url.QueryEscape(n.String())
-						logger.Printf("正在发起通知 -> %s\n", notificationUrl)
-						req, err := http.NewRequest("GET", notificationUrl, nil)
-						if err != nil {
-							logger.Printf("构建 Request 出错 -> %s", err)
-							break
-						}
-						req = req.WithContext(ctx)
-
-						resp, err := n.client.Do(req)
-
-						if err != nil {
-							logger.Printf("发起通知请求失败 -> %s - %s", notificationUrl, err)
-							break
-						}
-						if resp.StatusCode == http.StatusNotModified {
-							logger.Printf("服务器端配置未改变 -> %d", resp.StatusCode)
-							_ = resp.Body.Close()
-							break
-						}
-
-						body, err := ioutil.ReadAll(resp.Body)
-
-						_ = resp.Body.Close()
-
-						if err != nil {
-							logger.Printf("读取通知响应失败 -> %s - %s", notificationUrl, err)
-							break
-						}
-						if resp.StatusCode != http.StatusOK {
-							logger.Printf("服务器响应失败 -> %d - %s", resp.StatusCode, string(body))
-							break
-						}
-						logger.Printf("正在解析通知 -> %s - %s", notificationUrl, string(body))
-						var notifications []*Notification
-						err = json.Unmarshal(body, &notifications)
-						if err != nil {
-							logger.Printf("解析通知响应失败 -> %s - %s - %s", notificationUrl, string(body), err)
-							break
-						}
-						for i, item := range notifications {
-							//这里预防将删除后的通知再次存入到缓存中
-							if _, ok := n.notifications.Load(item.NamespaceName); ok {
-								n.notifications.Store(item.NamespaceName, item.NotificationId)
-								n.notificationCh <- notifications[i]
-							}
-						}
+				}
+				if err := n.poll(ctx); err != nil {
+					if ctx.Err() != nil {
+						return
 					}
+					delay := n.backoff.next(pollBackoffKey)
+					n.log().Printf("长轮询通知失败，%s 后重试 -> %s", delay, err)
+					if !sleep(ctx, delay) {
+						return
+					}
+					continue
 				}
+				n.backoff.reset(pollBackoffKey)
 			}
 		}()
 	})
@@ -146,6 +131,80 @@ func (n *notificationRepo) Watch() <-chan *Notification {
 	return n.notificationCh
 }
 
+// poll 发起一次长轮询，服务端返回 200 或 304 时视为成功.
+func (n *notificationRepo) poll(ctx context.Context) error {
+	host, err := n.services.current()
+	if err != nil {
+		return err
+	}
+	notificationUrl := fmt.Sprintf("%s/notifications/v2?appId=%s&cluster=%s&notifications=%s%s",
+		host,
+		url.QueryEscape(n.appId),
+		url.QueryEscape(n.cluster),
+		url.QueryEscape(n.String()),
+		clientQuery(n.ip, n.label),
+	)
+	n.log().Printf("正在发起通知 -> %s\n", notificationUrl)
+	req, err := http.NewRequest("GET", notificationUrl, nil)
+	if err != nil {
+		n.log().Printf("构建 Request 出错 -> %s", err)
+		return err
+	}
+	req = req.WithContext(ctx)
+	signRequest(req, n.appId, n.secret)
+
+	resp, err := n.client.Do(req)
+
+	if err != nil {
+		n.log().Printf("发起通知请求失败 -> %s - %s", notificationUrl, err)
+		n.services.failover(host)
+		return err
+	}
+	if resp.StatusCode < http.StatusInternalServerError {
+		n.services.succeed(host)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		n.log().Printf("服务器端配置未改变 -> %d", resp.StatusCode)
+		_ = resp.Body.Close()
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	_ = resp.Body.Close()
+
+	if err != nil {
+		n.log().Printf("读取通知响应失败 -> %s - %s", notificationUrl, err)
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		n.log().Printf("服务器响应失败 -> %d - %s", resp.StatusCode, string(body))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			n.services.failover(host)
+		}
+		return fmt.Errorf("服务器响应失败 -> %d - %s", resp.StatusCode, string(body))
+	}
+	n.log().Printf("正在解析通知 -> %s - %s", notificationUrl, string(body))
+	var notifications []*Notification
+	err = json.Unmarshal(body, &notifications)
+	if err != nil {
+		n.log().Printf("解析通知响应失败 -> %s - %s - %s", notificationUrl, string(body), err)
+		return err
+	}
+	for i, item := range notifications {
+		//这里预防将删除后的通知再次存入到缓存中
+		if _, ok := n.notifications.Load(item.NamespaceName); ok {
+			n.notifications.Store(item.NamespaceName, item.NotificationId)
+			select {
+			case n.notificationCh <- notifications[i]:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
 func (n *notificationRepo) String() string {
 	var notifications []Notification
 
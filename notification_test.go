@@ -1,20 +1,37 @@
 package goapollo
 
 import (
-	"context"
-	"os"
 	"testing"
+	"time"
+
+	"github.com/lifei6671/goapollo/apollotest"
 )
 
 func TestNotificationRepo_Watch(t *testing.T) {
-	host := os.Getenv("APOLLO_HOST")
-	appId := os.Getenv("APOLLO_APP_ID")
-	client := newNotificationRepo(host, appId, "default")
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"timeout": "100"})
 
+	client := newNotificationRepo(newStaticServices(server.URL), "SampleApp", "default")
+	defer client.Close()
 	client.AddNamespace("application")
 
 	select {
-	case notify := <-client.Watch(context.Background()):
-		t.Logf("%s", &notify)
+	case notify := <-client.Watch():
+		if notify.NamespaceName != "application" || notify.NotificationId <= 0 {
+			t.Fatalf("通知内容错误 -> %s", notify)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("未收到通知")
+	}
+
+	server.SetValue("SampleApp", "default", "application", "timeout", "200")
+	select {
+	case notify := <-client.Watch():
+		if notify.NamespaceName != "application" {
+			t.Fatalf("通知内容错误 -> %s", notify)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("配置变更后未收到通知")
 	}
 }
@@ -0,0 +1,65 @@
+package goapollo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// reload 重新读取命名空间的备份文件，配置有变化时发送与服务端推送相同的事件，仅用于离线模式.
+func (c *Client) reload(namespace string) error {
+	path, ok := c.caches.getSave(namespace)
+	if !ok {
+		return fmt.Errorf("备份文件不存在 -> %s", namespace)
+	}
+	event, err := c.caches.load(namespace, path)
+	if err != nil {
+		return err
+	}
+	if len(event.Changes) > 0 {
+		c.log().Printf("事件通知 -> %+v", event)
+		c.publish(event)
+	}
+	return nil
+}
+
+// watchFiles 定时检查离线模式下的备份文件，修改时间或大小变化时重新加载，直到 ctx 结束.
+// 第一次检查时会重新加载所有文件，避免遗漏初始加载后、开始检查前的修改.
+func (c *Client) watchFiles(ctx context.Context) {
+	if c.watchInterval <= 0 {
+		return
+	}
+	files := make(map[string]os.FileInfo)
+	changed := func(namespace string) bool {
+		path, ok := c.caches.getSave(namespace)
+		if !ok {
+			return false
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return false
+		}
+		prev, ok := files[namespace]
+		files[namespace] = fi
+		return !ok || !prev.ModTime().Equal(fi.ModTime()) || prev.Size() != fi.Size()
+	}
+
+	ticker := time.NewTicker(c.watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, namespace := range c.getNamespaces() {
+				if !changed(namespace) {
+					continue
+				}
+				if err := c.reload(namespace); err != nil {
+					c.log().Printf("重新加载备份文件失败 -> %s - %s", namespace, err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package goapollo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClient_Offline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "SampleApp", "application")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"namespace_name":"application","configurations":{"timeout":"100"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewWithConfig(Config{AppId: "SampleApp", CacheDir: dir, Namespaces: []string{"application"}}, WithOffline(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("创建客户端失败 -> %s", err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	if v, _ := c.GetValue("timeout"); v != "100" {
+		t.Fatalf("读取备份文件失败 -> %s", v)
+	}
+
+	body := []byte(`{"namespace_name":"application","configurations":{"timeout":"2000"}}`)
+	if err := ioutil.WriteFile(path, body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-c.WatchUpdate():
+		if change := event.Changes["timeout"]; change == nil || change.ChangeType != EventModify || change.NewValue != "2000" {
+			t.Fatalf("变更事件错误 -> %s", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("未发现文件修改")
+	}
+	_ = c.Close()
+
+	if saved, _ := ioutil.ReadFile(path); string(saved) != string(body) {
+		t.Fatalf("离线模式不应回写备份文件 -> %s", saved)
+	}
+}
+
+func TestClient_OfflineMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := NewWithConfig(Config{AppId: "SampleApp", CacheDir: dir, Offline: true, Namespaces: []string{"application"}})
+	if err != nil {
+		t.Fatalf("创建客户端失败 -> %s", err)
+	}
+	err = c.Run(context.Background())
+	if _, ok := err.(*LoadError); !ok {
+		t.Fatalf("备份文件不存在时应返回 LoadError -> %v", err)
+	}
+}
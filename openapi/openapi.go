@@ -0,0 +1,257 @@
+// Package openapi 是 Apollo 开放平台（Portal Open API）的客户端，用于在部署流水线中管理配置项和发布.
+//
+// 使用前需要在 Apollo Portal 的开放平台中创建第三方应用并授权，使用得到的 token 创建客户端：
+//
+//	client := openapi.New("http://portal:8070", "token")
+//	_, err := client.CreateItem("DEV", "SampleApp", "default", "application", &openapi.Item{
+//		Key:                 "timeout",
+//		Value:               "100",
+//		DataChangeCreatedBy: "apollo",
+//	})
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lifei6671/goapollo"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Error Portal 返回的错误.
+type Error struct {
+	StatusCode int    `json:"status"`
+	Exception  string `json:"exception"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("开放平台请求失败 -> %d - %s", e.StatusCode, e.Message)
+}
+
+// EnvCluster 应用在某个环境下的集群.
+type EnvCluster struct {
+	Env      string   `json:"env"`
+	Clusters []string `json:"clusters"`
+}
+
+// Item 配置项.
+type Item struct {
+	Key                        string `json:"key"`
+	Value                      string `json:"value"`
+	Comment                    string `json:"comment,omitempty"`
+	DataChangeCreatedBy        string `json:"dataChangeCreatedBy,omitempty"`
+	DataChangeLastModifiedBy   string `json:"dataChangeLastModifiedBy,omitempty"`
+	DataChangeCreatedTime      string `json:"dataChangeCreatedTime,omitempty"`
+	DataChangeLastModifiedTime string `json:"dataChangeLastModifiedTime,omitempty"`
+}
+
+// Namespace 命名空间及其未发布的配置项.
+type Namespace struct {
+	AppId         string  `json:"appId"`
+	ClusterName   string  `json:"clusterName"`
+	NamespaceName string  `json:"namespaceName"`
+	Comment       string  `json:"comment"`
+	Format        string  `json:"format"`
+	IsPublic      bool    `json:"isPublic"`
+	Items         []*Item `json:"items"`
+}
+
+// Configuration 将配置项转换为客户端使用的配置，忽略注释和空行.
+func (n *Namespace) Configuration() *goapollo.Configuration {
+	config := &goapollo.Configuration{NamespaceName: n.NamespaceName, Configurations: make(map[string]string, len(n.Items))}
+	for _, item := range n.Items {
+		if item.Key != "" {
+			config.Configurations[item.Key] = item.Value
+		}
+	}
+	return config
+}
+
+// ReleaseRequest 发布请求.
+type ReleaseRequest struct {
+	ReleaseTitle   string `json:"releaseTitle"`
+	ReleaseComment string `json:"releaseComment,omitempty"`
+	ReleasedBy     string `json:"releasedBy"`
+}
+
+// Release 已发布的版本.
+type Release struct {
+	Id             int64             `json:"id"`
+	AppId          string            `json:"appId"`
+	ClusterName    string            `json:"clusterName"`
+	NamespaceName  string            `json:"namespaceName"`
+	Name           string            `json:"name"`
+	Configurations map[string]string `json:"configurations"`
+	Comment        string            `json:"comment"`
+}
+
+// Configuration 将发布的版本转换为客户端使用的配置.
+func (r *Release) Configuration() *goapollo.Configuration {
+	return &goapollo.Configuration{NamespaceName: r.NamespaceName, Configurations: r.Configurations}
+}
+
+// Client 开放平台客户端.
+type Client struct {
+	portal string
+	token  string
+	client *http.Client
+}
+
+// New 使用 Portal 地址和开放平台 token 创建客户端.
+func New(portal, token string) *Client {
+	return &Client{
+		portal: strings.TrimRight(portal, "/"),
+		token:  token,
+		client: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// SetHTTPClient 设置自定义的 HTTP 客户端.
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+// EnvClusters 获取应用在各个环境下的集群.
+func (c *Client) EnvClusters(appId string) ([]*EnvCluster, error) {
+	var clusters []*EnvCluster
+	err := c.do(http.MethodGet, fmt.Sprintf("/openapi/v1/apps/%s/envclusters", url.PathEscape(appId)), nil, &clusters)
+	return clusters, err
+}
+
+// Namespaces 获取集群下的所有命名空间.
+func (c *Client) Namespaces(env, appId, cluster string) ([]*Namespace, error) {
+	var namespaces []*Namespace
+	err := c.do(http.MethodGet, clusterPath(env, appId, cluster)+"/namespaces", nil, &namespaces)
+	return namespaces, err
+}
+
+// Namespace 获取命名空间.
+func (c *Client) Namespace(env, appId, cluster, namespace string) (*Namespace, error) {
+	var ns Namespace
+	if err := c.do(http.MethodGet, namespacePath(env, appId, cluster, namespace), nil, &ns); err != nil {
+		return nil, err
+	}
+	return &ns, nil
+}
+
+// Item 获取配置项.
+func (c *Client) Item(env, appId, cluster, namespace, key string) (*Item, error) {
+	var item Item
+	if err := c.do(http.MethodGet, itemPath(env, appId, cluster, namespace, key), nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// CreateItem 新增配置项，item.DataChangeCreatedBy 为必填的操作人.
+func (c *Client) CreateItem(env, appId, cluster, namespace string, item *Item) (*Item, error) {
+	var created Item
+	if err := c.do(http.MethodPost, namespacePath(env, appId, cluster, namespace)+"/items", item, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateItem 修改配置项，item.DataChangeLastModifiedBy 为必填的操作人，createIfNotExists 为 true 时配置项不存在则新增.
+func (c *Client) UpdateItem(env, appId, cluster, namespace string, item *Item, createIfNotExists bool) error {
+	path := itemPath(env, appId, cluster, namespace, item.Key)
+	// 复制一份再补充创建人，不修改调用方传入的配置项
+	body := *item
+	if createIfNotExists {
+		path += "?createIfNotExists=true"
+		if body.DataChangeCreatedBy == "" {
+			body.DataChangeCreatedBy = body.DataChangeLastModifiedBy
+		}
+	}
+	return c.do(http.MethodPut, path, &body, nil)
+}
+
+// DeleteItem 删除配置项.
+func (c *Client) DeleteItem(env, appId, cluster, namespace, key, operator string) error {
+	path := itemPath(env, appId, cluster, namespace, key) + "?operator=" + url.QueryEscape(operator)
+	return c.do(http.MethodDelete, path, nil, nil)
+}
+
+// Publish 发布命名空间当前的配置.
+func (c *Client) Publish(env, appId, cluster, namespace string, release *ReleaseRequest) (*Release, error) {
+	var published Release
+	if err := c.do(http.MethodPost, namespacePath(env, appId, cluster, namespace)+"/releases", release, &published); err != nil {
+		return nil, err
+	}
+	return &published, nil
+}
+
+// LatestRelease 获取命名空间最新的有效发布.
+func (c *Client) LatestRelease(env, appId, cluster, namespace string) (*Release, error) {
+	var release Release
+	if err := c.do(http.MethodGet, namespacePath(env, appId, cluster, namespace)+"/releases/latest", nil, &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Rollback 回滚指定的发布，回滚后命名空间恢复到上一个版本.
+func (c *Client) Rollback(env string, releaseId int64, operator string) error {
+	path := fmt.Sprintf("/openapi/v1/envs/%s/releases/%d/rollback?operator=%s", url.PathEscape(env), releaseId, url.QueryEscape(operator))
+	return c.do(http.MethodPut, path, nil, nil)
+}
+
+func (c *Client) do(method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.portal+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.token)
+	req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{}
+		if json.Unmarshal(b, e) != nil || e.Message == "" {
+			e.Message = string(b)
+		}
+		e.StatusCode = resp.StatusCode
+		return e
+	}
+	if v == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+func clusterPath(env, appId, cluster string) string {
+	return fmt.Sprintf("/openapi/v1/envs/%s/apps/%s/clusters/%s", url.PathEscape(env), url.PathEscape(appId), url.PathEscape(cluster))
+}
+
+func namespacePath(env, appId, cluster, namespace string) string {
+	return clusterPath(env, appId, cluster) + "/namespaces/" + url.PathEscape(namespace)
+}
+
+func itemPath(env, appId, cluster, namespace, key string) string {
+	return namespacePath(env, appId, cluster, namespace) + "/items/" + url.PathEscape(key)
+}
@@ -0,0 +1,99 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Items(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application":
+			_, _ = w.Write([]byte(`{"appId":"SampleApp","clusterName":"default","namespaceName":"application","format":"properties","items":[{"key":"timeout","value":"100"},{"key":"","value":"","comment":"注释"}]}`))
+		case "POST /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items":
+			_, _ = w.Write(body)
+		case "PUT /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items/timeout",
+			"DELETE /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items/timeout",
+			"PUT /openapi/v1/envs/DEV/releases/12/rollback":
+		case "POST /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/releases":
+			_, _ = w.Write([]byte(`{"id":12,"appId":"SampleApp","clusterName":"default","namespaceName":"application","name":"v1","configurations":{"timeout":"200"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"message":"namespace not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", "token")
+	ns, err := c.Namespace("DEV", "SampleApp", "default", "application")
+	if err != nil {
+		t.Fatalf("获取命名空间失败 -> %s", err)
+	}
+	if config := ns.Configuration(); len(config.Configurations) != 1 || config.Configurations["timeout"] != "100" {
+		t.Fatalf("转换配置错误 -> %s", config)
+	}
+
+	item, err := c.CreateItem("DEV", "SampleApp", "default", "application", &Item{Key: "timeout", Value: "100", DataChangeCreatedBy: "apollo"})
+	if err != nil || item.Key != "timeout" {
+		t.Fatalf("新增配置项失败 -> %+v - %v", item, err)
+	}
+	update := &Item{Key: "timeout", Value: "200", DataChangeLastModifiedBy: "apollo"}
+	if err := c.UpdateItem("DEV", "SampleApp", "default", "application", update, true); err != nil {
+		t.Fatalf("修改配置项失败 -> %s", err)
+	}
+	if update.DataChangeCreatedBy != "" {
+		t.Fatalf("不应修改传入的配置项 -> %+v", update)
+	}
+	release, err := c.Publish("DEV", "SampleApp", "default", "application", &ReleaseRequest{ReleaseTitle: "v1", ReleasedBy: "apollo"})
+	if err != nil || release.Id != 12 || release.Configuration().Configurations["timeout"] != "200" {
+		t.Fatalf("发布失败 -> %+v - %v", release, err)
+	}
+	if err := c.Rollback("DEV", release.Id, "apollo"); err != nil {
+		t.Fatalf("回滚失败 -> %s", err)
+	}
+	if err := c.DeleteItem("DEV", "SampleApp", "default", "application", "timeout", "apollo"); err != nil {
+		t.Fatalf("删除配置项失败 -> %s", err)
+	}
+
+	expected := []string{
+		"GET /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application ",
+		`POST /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items {"key":"timeout","value":"100","dataChangeCreatedBy":"apollo"}`,
+		`PUT /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items/timeout?createIfNotExists=true {"key":"timeout","value":"200","dataChangeCreatedBy":"apollo","dataChangeLastModifiedBy":"apollo"}`,
+		`POST /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/releases {"releaseTitle":"v1","releasedBy":"apollo"}`,
+		"PUT /openapi/v1/envs/DEV/releases/12/rollback?operator=apollo ",
+		"DELETE /openapi/v1/envs/DEV/apps/SampleApp/clusters/default/namespaces/application/items/timeout?operator=apollo ",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("请求数量错误 -> %v", requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Fatalf("请求错误 -> %s\n期望 -> %s", requests[i], expected[i])
+		}
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": 400, "exception": "BadRequestException", "message": "item already exists"})
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, "token").CreateItem("DEV", "SampleApp", "default", "application", &Item{Key: "timeout"})
+	e, ok := err.(*Error)
+	if !ok || e.StatusCode != http.StatusBadRequest || e.Message != "item already exists" {
+		t.Fatalf("错误信息不正确 -> %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package goapollo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Option 修改客户端配置，返回错误时 NewWithConfig 创建失败.
+type Option func(conf *Config) error
+
+// WithHost 设置配置服务地址，同时清除 Meta Server 地址.
+func WithHost(host string) Option {
+	return func(conf *Config) error {
+		conf.Host = host
+		conf.MetaServer = ""
+		return nil
+	}
+}
+
+// WithMetaServer 设置 Meta Server 地址，多个可用逗号分隔，同时清除配置服务地址.
+func WithMetaServer(meta string) Option {
+	return func(conf *Config) error {
+		conf.MetaServer = meta
+		conf.Host = ""
+		return nil
+	}
+}
+
+// WithCluster 设置集群名称.
+func WithCluster(cluster string) Option {
+	return func(conf *Config) error {
+		conf.Cluster = cluster
+		return nil
+	}
+}
+
+// WithNamespaces 追加初始命名空间.
+func WithNamespaces(namespaces ...string) Option {
+	return func(conf *Config) error {
+		conf.Namespaces = append(conf.Namespaces, namespaces...)
+		return nil
+	}
+}
+
+// WithHTTPClient 设置自定义 HTTP 客户端，长轮询请求会复制该客户端并使用 LongPollTimeout 作为超时时间.
+func WithHTTPClient(client *http.Client) Option {
+	return func(conf *Config) error {
+		if client == nil {
+			return errors.New("HTTPClient 不能为空")
+		}
+		conf.HTTPClient = client
+		return nil
+	}
+}
+
+// WithTransport 设置自定义 HTTP 传输层.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(conf *Config) error {
+		if transport == nil {
+			return errors.New("Transport 不能为空")
+		}
+		conf.Transport = transport
+		return nil
+	}
+}
+
+// WithTimeout 设置获取配置请求的超时时间.
+func WithTimeout(timeout time.Duration) Option {
+	return func(conf *Config) error {
+		if timeout <= 0 {
+			return errors.New("Timeout 必须大于 0")
+		}
+		conf.Timeout = timeout
+		return nil
+	}
+}
+
+// WithLongPollTimeout 设置长轮询通知请求的超时时间.
+func WithLongPollTimeout(timeout time.Duration) Option {
+	return func(conf *Config) error {
+		if timeout <= 0 {
+			return errors.New("LongPollTimeout 必须大于 0")
+		}
+		conf.LongPollTimeout = timeout
+		return nil
+	}
+}
+
+// WithCacheDir 设置本地备份目录.
+func WithCacheDir(dir string) Option {
+	return func(conf *Config) error {
+		if dir == "" {
+			return errors.New("CacheDir 不能为空")
+		}
+		conf.CacheDir = dir
+		return nil
+	}
+}
+
+// WithClientIP 设置客户端 IP，未设置时自动选择本机的非回环地址.
+func WithClientIP(ip string) Option {
+	return func(conf *Config) error {
+		conf.IP = ip
+		return nil
+	}
+}
+
+// WithIPPreference 设置自动选择客户端 IP 时的偏好，可以是网卡名称（如 eth0）或 CIDR（如 10.0.0.0/8）.
+func WithIPPreference(preferences ...string) Option {
+	return func(conf *Config) error {
+		conf.IPPreference = preferences
+		return nil
+	}
+}
+
+// WithLabel 设置客户端标签，支持多个标签.
+func WithLabel(labels ...string) Option {
+	return func(conf *Config) error {
+		conf.Label = joinLabels(labels...)
+		return nil
+	}
+}
+
+// WithAccessKeySecret 设置访问密钥.
+func WithAccessKeySecret(secret string) Option {
+	return func(conf *Config) error {
+		conf.Secret = secret
+		return nil
+	}
+}
+
+// WithLogger 设置客户端使用的日志接口.
+func WithLogger(logger ILogger) Option {
+	return func(conf *Config) error {
+		if logger == nil {
+			return errors.New("Logger 不能为空")
+		}
+		conf.Logger = logger
+		return nil
+	}
+}
+
+// WithEventBufferSize 设置变更事件通道的缓冲大小.
+func WithEventBufferSize(size int) Option {
+	return func(conf *Config) error {
+		if size < 0 {
+			return errors.New("EventBufferSize 不能小于 0")
+		}
+		conf.EventBufferSize = size
+		return nil
+	}
+}
+
+// WithOffline 开启离线模式，只从本地备份文件加载配置，watchInterval 大于 0 时定时检查文件修改.
+func WithOffline(watchInterval time.Duration) Option {
+	return func(conf *Config) error {
+		if watchInterval < 0 {
+			return errors.New("WatchInterval 不能小于 0")
+		}
+		conf.Offline = true
+		conf.WatchInterval = watchInterval
+		return nil
+	}
+}
+
+// WithDecryptor 设置解密 ENC(...) 格式配置值的解密器.
+func WithDecryptor(decryptor Decryptor) Option {
+	return func(conf *Config) error {
+		if decryptor == nil {
+			return errors.New("Decryptor 不能为空")
+		}
+		conf.Decryptor = decryptor
+		return nil
+	}
+}
+
+// WithSerializer 设置 AddNamespace 使用的序列化器，例如使用 EncryptedSerializer 加密备份文件.
+func WithSerializer(serializer Serializer) Option {
+	return func(conf *Config) error {
+		if serializer == nil {
+			return errors.New("Serializer 不能为空")
+		}
+		conf.Serializer = serializer
+		return nil
+	}
+}
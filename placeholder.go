@@ -0,0 +1,194 @@
+package goapollo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	placeholderPrefix = "${"
+	placeholderSuffix = '}'
+)
+
+// valueGetter 获取命名空间中配置的原始值.
+type valueGetter func(namespace, key string) (string, bool)
+
+// resolve 解析 value 中的占位符，支持 ${key}、${key:默认值}、${命名空间:key} 和 ${命名空间:key:默认值}，默认值中可以嵌套占位符.
+// 冒号前为已添加的命名空间时按 ${命名空间:key} 解析，否则按 ${key:默认值} 解析；无法解析且没有默认值的占位符保持原样.
+// visiting 记录解析链上的配置，出现循环引用时返回错误.
+func (c *Client) resolve(namespace, value string, get valueGetter, visiting map[string]bool) (string, error) {
+	if !strings.Contains(value, placeholderPrefix) {
+		return value, nil
+	}
+	var buf strings.Builder
+	for {
+		start := strings.Index(value, placeholderPrefix)
+		if start < 0 {
+			break
+		}
+		end := matchPlaceholder(value, start+len(placeholderPrefix))
+		if end < 0 {
+			break
+		}
+		resolved, err := c.resolvePlaceholder(namespace, value[start+len(placeholderPrefix):end], get, visiting)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(value[:start])
+		buf.WriteString(resolved)
+		value = value[end+1:]
+	}
+	buf.WriteString(value)
+	return buf.String(), nil
+}
+
+func (c *Client) resolvePlaceholder(namespace, expr string, get valueGetter, visiting map[string]bool) (string, error) {
+	refNamespace, key, def, hasDefault := namespace, expr, "", false
+	if i := splitPlaceholder(expr); i >= 0 {
+		key, def, hasDefault = expr[:i], expr[i+1:], true
+		if c.hasNamespace(key) {
+			refNamespace, key, def, hasDefault = key, def, "", false
+			if j := splitPlaceholder(key); j >= 0 {
+				key, def, hasDefault = key[:j], key[j+1:], true
+			}
+		}
+	}
+
+	if val, ok := get(refNamespace, key); ok {
+		id := placeholderId(refNamespace, key)
+		if visiting[id] {
+			return "", fmt.Errorf("占位符存在循环引用 -> %s:%s", refNamespace, key)
+		}
+		visiting[id] = true
+		defer delete(visiting, id)
+		return c.resolve(refNamespace, val, get, visiting)
+	}
+	if hasDefault {
+		return c.resolve(namespace, def, get, visiting)
+	}
+	return placeholderPrefix + expr + string(placeholderSuffix), nil
+}
+
+// matchPlaceholder 查找与占位符开始位置匹配的结束位置，未找到时返回 -1.
+func matchPlaceholder(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], placeholderPrefix) {
+			depth++
+			i++
+		} else if s[i] == placeholderSuffix {
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitPlaceholder 查找不在嵌套占位符中的第一个冒号，未找到时返回 -1.
+func splitPlaceholder(expr string) int {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case strings.HasPrefix(expr[i:], placeholderPrefix):
+			depth++
+			i++
+		case expr[i] == placeholderSuffix:
+			depth--
+		case expr[i] == ':' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+func placeholderId(namespace, key string) string {
+	return namespace + "\x00" + key
+}
+
+func (c *Client) hasNamespace(namespace string) bool {
+	c.rmx.RLock()
+	defer c.rmx.RUnlock()
+	for _, name := range c.namespaces {
+		if name == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveValue 获取解析占位符后的配置，解析失败时记录日志并返回原始值.
+func (c *Client) resolveValue(namespace, key string, get valueGetter) (string, bool) {
+	val, ok := get(namespace, key)
+	if !ok {
+		return val, false
+	}
+	resolved, err := c.resolve(namespace, val, get, map[string]bool{placeholderId(namespace, key): true})
+	if err != nil {
+		c.log().Printf("解析占位符失败 -> %s - %s - %s", namespace, key, err)
+		return val, true
+	}
+	return resolved, true
+}
+
+// withDependents 返回变更事件以及因引用了变更的配置而导致解析结果变化的配置事件.
+// 同一命名空间中的依赖配置会合并到原事件中，其他命名空间各自生成一个事件，事件中直接变更和依赖配置的值都是解析后的值.
+func (c *Client) withDependents(event *ChangeEvent) []*ChangeEvent {
+	if len(event.Changes) == 0 {
+		return []*ChangeEvent{event}
+	}
+	// 变更前的视图：使用事件中的旧值覆盖缓存
+	old := func(namespace, key string) (string, bool) {
+		if namespace == event.Namespace {
+			if change, ok := event.Changes[key]; ok {
+				return change.OldValue, change.ChangeType != EventAdd
+			}
+		}
+		return c.caches.get(namespace, key)
+	}
+	// 直接变更的配置同样使用解析后的值，等依赖配置计算完成后再替换，避免影响变更前的视图
+	direct := make(map[string]*Change, len(event.Changes))
+	for key, change := range event.Changes {
+		resolved := *change
+		if change.ChangeType != EventAdd {
+			resolved.OldValue, _ = c.resolveValue(event.Namespace, key, old)
+		}
+		if change.ChangeType != EventDelete {
+			resolved.NewValue, _ = c.resolveValue(event.Namespace, key, c.caches.get)
+		}
+		direct[key] = &resolved
+	}
+
+	events := []*ChangeEvent{event}
+	for _, namespace := range c.getNamespaces() {
+		var dependent *ChangeEvent
+		if namespace == event.Namespace {
+			dependent = event
+		}
+		for _, key := range c.caches.keys(namespace) {
+			if namespace == event.Namespace {
+				if _, ok := event.Changes[key]; ok {
+					continue
+				}
+			}
+			raw, ok := c.caches.get(namespace, key)
+			if !ok || !strings.Contains(raw, placeholderPrefix) {
+				continue
+			}
+			oldValue, _ := c.resolveValue(namespace, key, old)
+			newValue, _ := c.resolveValue(namespace, key, c.caches.get)
+			if oldValue == newValue {
+				continue
+			}
+			if dependent == nil {
+				dependent = &ChangeEvent{Namespace: namespace, Changes: make(map[string]*Change)}
+				events = append(events, dependent)
+			}
+			dependent.Changes[key] = &Change{OldValue: oldValue, NewValue: newValue, ChangeType: EventModify}
+		}
+	}
+	for key, change := range direct {
+		event.Changes[key] = change
+	}
+	return events
+}
@@ -0,0 +1,143 @@
+package goapollo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lifei6671/goapollo/apollotest"
+)
+
+func TestClient_Placeholder(t *testing.T) {
+	c := New("http://localhost:8080", "SampleApp", "default")
+	c.addNamespace("application")
+	c.addNamespace("common")
+	mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{
+		"db.host":  "127.0.0.1",
+		"db.url":   "jdbc:mysql://${db.host}:${db.port:3306}/app",
+		"region":   "${common:region}",
+		"zone":     "${common:zone:${region}-a}",
+		"nested":   "${missing:${db.host}}",
+		"unknown":  "${missing}",
+		"port":     "${db.port:3306}",
+		"cycle.a":  "${cycle.b}",
+		"cycle.b":  "${cycle.a}",
+		"self":     "x${self}",
+		"literal":  "${",
+		"trailing": "${db.host}}",
+	}})
+	mustStore(t, c.caches, result{NamespaceName: "common", Configurations: map[string]string{"region": "sh"}})
+
+	cases := map[string]string{
+		"db.url":   "jdbc:mysql://127.0.0.1:3306/app",
+		"region":   "sh",
+		"zone":     "sh-a",
+		"nested":   "127.0.0.1",
+		"unknown":  "${missing}",
+		"cycle.a":  "${cycle.b}",
+		"self":     "x${self}",
+		"literal":  "${",
+		"trailing": "127.0.0.1}",
+	}
+	for key, expected := range cases {
+		if v, _ := c.GetValue(key); v != expected {
+			t.Errorf("解析占位符错误 -> %s - %s", key, v)
+		}
+	}
+	if port := c.GetInt("port", 0); port != 3306 {
+		t.Errorf("类型化取值未解析占位符 -> %d", port)
+	}
+}
+
+func TestClient_PlaceholderDependents(t *testing.T) {
+	server := apollotest.NewServer()
+	defer server.Close()
+	server.Publish("SampleApp", "default", "application", map[string]string{"db.host": "a", "db.url": "mysql://${db.host}/app", "name": "${common:name}"})
+	server.Publish("SampleApp", "default", "common", map[string]string{"name": "demo"})
+
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(server.URL, "SampleApp", "default")
+	c.SetCacheDir(dir)
+	c.AddNamespace("application").AddNamespace("common")
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	defer c.Close()
+
+	// 丢弃初始加载的事件
+	for drained := false; !drained; {
+		select {
+		case <-c.WatchUpdate():
+		case <-time.After(200 * time.Millisecond):
+			drained = true
+		}
+	}
+
+	server.SetValue("SampleApp", "default", "application", "db.host", "b")
+	event := waitChangeEvent(t, c)
+	if change := event.Changes["db.url"]; change == nil || change.OldValue != "mysql://a/app" || change.NewValue != "mysql://b/app" {
+		t.Fatalf("未发送依赖配置的变更 -> %s", event)
+	}
+
+	server.SetValue("SampleApp", "default", "common", "name", "demo2")
+	for _, namespace := range []string{"common", "application"} {
+		event := waitChangeEvent(t, c)
+		if event.Namespace != namespace {
+			t.Fatalf("事件顺序错误 -> %s", event)
+		}
+		if namespace == "application" {
+			if change := event.Changes["name"]; change == nil || change.NewValue != "demo2" || len(event.Changes) != 1 {
+				t.Fatalf("未发送其他命名空间依赖配置的变更 -> %s", event)
+			}
+		}
+	}
+}
+
+func TestClient_PlaceholderMixedEvent(t *testing.T) {
+	c := New("http://localhost:8080", "SampleApp", "default")
+	c.addNamespace("application")
+	mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{
+		"db.host": "a",
+		"db.port": "3306",
+		"db.url":  "mysql://${db.host}:${db.port}/app",
+		"db.dsn":  "${db.url}?timeout=1s",
+	}})
+
+	// 同一次发布中直接修改了引用占位符的配置，也修改了被引用的配置
+	event := mustStore(t, c.caches, result{NamespaceName: "application", Configurations: map[string]string{
+		"db.host": "b",
+		"db.port": "3306",
+		"db.url":  "mysql://${db.host}:${db.port}/demo",
+		"db.dsn":  "${db.url}?timeout=1s",
+	}})
+	events := c.withDependents(event)
+	if len(events) != 1 || len(event.Changes) != 3 {
+		t.Fatalf("变更事件错误 -> %v", events)
+	}
+	expected := map[string][2]string{
+		"db.host": {"a", "b"},
+		"db.url":  {"mysql://a:3306/app", "mysql://b:3306/demo"},
+		"db.dsn":  {"mysql://a:3306/app?timeout=1s", "mysql://b:3306/demo?timeout=1s"},
+	}
+	for key, values := range expected {
+		if change := event.Changes[key]; change == nil || change.OldValue != values[0] || change.NewValue != values[1] {
+			t.Errorf("事件中的值应为解析后的值 -> %s - %+v", key, change)
+		}
+	}
+}
+
+func waitChangeEvent(t *testing.T, c *Client) *ChangeEvent {
+	select {
+	case event := <-c.WatchUpdate():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("未收到变更事件")
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package goapollo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// propertiesMeta properties 备份文件中保存命名空间信息的注释前缀，Java 客户端读取时会当作注释忽略.
+const propertiesMeta = "#@"
+
+// PropertiesSerializer 使用与 Java 客户端备份文件相同的 .properties 格式，非 ASCII 字符转义为 \uXXXX.
+// 命名空间名称、ReleaseKey 和通知 ID 以 #@ 开头的注释保存.
+type PropertiesSerializer struct{}
+
+func NewPropertiesSerializer() *PropertiesSerializer {
+	return &PropertiesSerializer{}
+}
+
+func (p *PropertiesSerializer) Serialize(v *Configuration) ([]byte, error) {
+	var buf bytes.Buffer
+	writeProperty(&buf, propertiesMeta+"namespace_name", v.NamespaceName)
+	writeProperty(&buf, propertiesMeta+"release_key", v.ReleaseKey)
+	if v.NotificationId != 0 {
+		writeProperty(&buf, propertiesMeta+"notification_id", strconv.Itoa(v.NotificationId))
+	}
+
+	keys := make([]string, 0, len(v.Configurations))
+	for key := range v.Configurations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeProperty(&buf, escapeProperty(key, true), v.Configurations[key])
+	}
+	return buf.Bytes(), nil
+}
+
+func writeProperty(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	buf.WriteString(escapeProperty(value, false))
+	buf.WriteByte('\n')
+}
+
+// escapeProperty 按 java.util.Properties#store 的规则转义，键中的空格都需要转义，值只转义开头的空格.
+func escapeProperty(s string, key bool) string {
+	var buf strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			buf.WriteString(`\\`)
+		case ' ':
+			if i == 0 || key {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(' ')
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, u := range utf16.Encode([]rune{r}) {
+					_, _ = fmt.Fprintf(&buf, `\u%04X`, u)
+				}
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func (p *PropertiesSerializer) Deserialize(body []byte, target *Configuration) error {
+	target.Configurations = make(map[string]string)
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(body)), "\n")
+	for i := 0; i < len(lines); i++ {
+		no := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" {
+			continue
+		}
+		if line[0] == '#' || line[0] == '!' {
+			if !strings.HasPrefix(line, propertiesMeta) {
+				continue
+			}
+			key, value, err := parseProperty(line)
+			if err != nil {
+				return fmt.Errorf("解析 properties 失败 -> 第 %d 行 - %s", no, err)
+			}
+			switch strings.TrimPrefix(key, propertiesMeta) {
+			case "namespace_name":
+				target.NamespaceName = value
+			case "release_key":
+				target.ReleaseKey = value
+			case "notification_id":
+				if target.NotificationId, err = strconv.Atoi(value); err != nil {
+					return fmt.Errorf("解析 properties 失败 -> 第 %d 行 - %s", no, err)
+				}
+			}
+			continue
+		}
+		// 以奇数个反斜杠结尾的行与下一行合并，下一行开头的空白会被忽略
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continued(line) {
+			line = line[:len(line)-1]
+		}
+		key, value, err := parseProperty(line)
+		if err != nil {
+			return fmt.Errorf("解析 properties 失败 -> 第 %d 行 - %s", no, err)
+		}
+		target.Configurations[key] = value
+	}
+	return nil
+}
+
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// parseProperty 解析一行配置，键与值之间使用 =、: 或空白分隔.
+func parseProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rest := line[end:]
+	if rest == "" || (rest[0] != '=' && rest[0] != ':') {
+		rest = strings.TrimLeft(rest, " \t\f")
+	}
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = rest[1:]
+	}
+	value := strings.TrimLeft(rest, " \t\f")
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err = unescapeProperty(value)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// unescapeProperty 还原转义字符，\uXXXX 按 UTF-16 解码，未转义的 UTF-8 字符保持原样.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var buf strings.Builder
+	var units []uint16
+	flush := func() {
+		if len(units) > 0 {
+			buf.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			flush()
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		if s[i] == 'u' {
+			if i+5 > len(s) {
+				return "", fmt.Errorf("无效的 \\u 转义 -> %s", s[i-1:])
+			}
+			u, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("无效的 \\u 转义 -> %s", s[i-1:i+5])
+			}
+			units = append(units, uint16(u))
+			i += 4
+			continue
+		}
+		flush()
+		switch s[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	flush()
+	return buf.String(), nil
+}
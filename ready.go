@@ -0,0 +1,110 @@
+package goapollo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultReadyTimeout = 30 * time.Second
+
+// ReadyPolicy 决定初始加载失败时客户端是否视为就绪.
+type ReadyPolicy int
+
+const (
+	// ReadyPolicyCache 从配置服务拉取失败时，已从本地备份加载的命名空间视为就绪.
+	ReadyPolicyCache ReadyPolicy = iota
+	// ReadyPolicyRemote 所有命名空间都必须从配置服务拉取成功.
+	ReadyPolicyRemote
+	// ReadyPolicyNone 不校验初始加载结果，失败时仅记录日志.
+	ReadyPolicyNone
+)
+
+func (p ReadyPolicy) String() string {
+	switch p {
+	case ReadyPolicyCache:
+		return "CACHE"
+	case ReadyPolicyRemote:
+		return "REMOTE"
+	case ReadyPolicyNone:
+		return "NONE"
+	}
+	return "UNKNOWN"
+}
+
+// LoadError 记录初始加载失败的命名空间及原因.
+type LoadError struct {
+	Namespaces map[string]error
+}
+
+func (e *LoadError) Error() string {
+	names := make([]string, 0, len(e.Namespaces))
+	for name := range e.Namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		items = append(items, fmt.Sprintf("%s: %s", name, e.Namespaces[name]))
+	}
+	return "初始加载命名空间失败 -> " + strings.Join(items, "; ")
+}
+
+// load 并发拉取所有已注册的命名空间，并根据就绪策略判断是否加载成功.
+func (c *Client) load(ctx context.Context) error {
+	if c.readyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.readyTimeout)
+		defer cancel()
+	}
+	type outcome struct {
+		namespace string
+		err       error
+	}
+	namespaces := c.getNamespaces()
+	pending := make(map[string]bool, len(namespaces))
+	results := make(chan outcome, len(namespaces))
+
+	for _, namespace := range namespaces {
+		pending[namespace] = true
+		go func(namespace string) {
+			results <- outcome{namespace: namespace, err: c.refresh(namespace)}
+		}(namespace)
+	}
+
+	failed := make(map[string]error)
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.namespace)
+			if r.err != nil {
+				failed[r.namespace] = r.err
+			}
+		case <-ctx.Done():
+			for namespace := range pending {
+				failed[namespace] = ctx.Err()
+			}
+			pending = nil
+		}
+	}
+
+	for namespace, err := range failed {
+		c.log().Printf("初始加载命名空间失败 -> %s - %s - %s - %s", c.appId, c.cluster, namespace, err)
+		switch c.readyPolicy {
+		case ReadyPolicyNone:
+			delete(failed, namespace)
+		case ReadyPolicyCache:
+			if c.caches.has(namespace) {
+				c.log().Printf("使用本地备份配置 -> %s", namespace)
+				delete(failed, namespace)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return &LoadError{Namespaces: failed}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package goapollo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClient_RunReadyPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/configs/SampleApp/default/application"):
+			_, _ = w.Write([]byte(`{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"20200101"}`))
+		case strings.HasPrefix(r.URL.Path, "/configs/"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "goapollo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backup := filepath.Join(dir, "wechat")
+	if err := ioutil.WriteFile(backup, []byte(`{"namespace_name":"wechat","configurations":{"TIMEOUT":"30"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(server.URL, "SampleApp", "default")
+	c.SetReadyPolicy(ReadyPolicyRemote)
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), filepath.Join(dir, "application")).
+		AddNamespaceWithSerializerWithPath("wechat", NewJsonSerializer(), backup)
+
+	err = c.Run(context.Background())
+	loadErr, ok := err.(*LoadError)
+	if !ok {
+		t.Fatalf("期望返回 LoadError -> %v", err)
+	}
+	if _, ok := loadErr.Namespaces["wechat"]; !ok || len(loadErr.Namespaces) != 1 {
+		t.Fatalf("失败的命名空间错误 -> %s", loadErr)
+	}
+	if val, _ := c.GetValue("timeout"); val != "100" {
+		t.Fatalf("配置值错误 -> %s", val)
+	}
+
+	c.SetReadyPolicy(ReadyPolicyCache)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("使用本地备份时应视为就绪 -> %s", err)
+	}
+	defer c.Close()
+	if val, _ := c.GetValueWithNamespace("wechat", "TIMEOUT"); val != "30" {
+		t.Fatalf("备份配置值错误 -> %s", val)
+	}
+}
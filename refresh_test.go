@@ -0,0 +1,62 @@
+package goapollo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_PeriodicRefresh(t *testing.T) {
+	mux := &sync.Mutex{}
+	releaseKey, timeout := "1", "100"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/configs/") {
+			<-r.Context().Done()
+			return
+		}
+		mux.Lock()
+		defer mux.Unlock()
+		if r.URL.Query().Get("releaseKey") == releaseKey {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"%s"},"releaseKey":"%s"}`, timeout, releaseKey)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "SampleApp", "default")
+	c.SetRefreshInterval(20 * time.Millisecond)
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), "")
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("启动客户端失败 -> %s", err)
+	}
+	defer c.Close()
+
+	if event := <-c.WatchUpdate(); event.Changes["timeout"].ChangeType != EventAdd {
+		t.Fatalf("初始事件错误 -> %s", event)
+	}
+	select {
+	case event := <-c.WatchUpdate():
+		t.Fatalf("配置未变更时不应发送事件 -> %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mux.Lock()
+	releaseKey, timeout = "2", "200"
+	mux.Unlock()
+
+	select {
+	case event := <-c.WatchUpdate():
+		if change := event.Changes["timeout"]; change.ChangeType != EventModify || change.NewValue != "200" {
+			t.Fatalf("变更事件错误 -> %s", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("定时拉取未发现变更")
+	}
+}
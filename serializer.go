@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+
+	"gopkg.in/yaml.v2"
 )
 
 type Serializer interface {
@@ -42,3 +44,39 @@ func (j *GobSerializer) Deserialize(body []byte, target *Configuration) error {
 	err := decoder.Decode(target)
 	return err
 }
+
+// yamlConfiguration yaml 备份文件的结构，字段名与 json 序列化器保持一致.
+type yamlConfiguration struct {
+	NamespaceName  string            `yaml:"namespace_name"`
+	ReleaseKey     string            `yaml:"release_key"`
+	NotificationId int               `yaml:"notification_id,omitempty"`
+	Configurations map[string]string `yaml:"configurations"`
+}
+
+// YamlSerializer 使用 yaml 格式保存备份，便于手工查看和修改.
+type YamlSerializer struct{}
+
+func NewYamlSerializer() *YamlSerializer {
+	return &YamlSerializer{}
+}
+
+func (y *YamlSerializer) Serialize(v *Configuration) ([]byte, error) {
+	return yaml.Marshal(&yamlConfiguration{
+		NamespaceName:  v.NamespaceName,
+		ReleaseKey:     v.ReleaseKey,
+		NotificationId: v.NotificationId,
+		Configurations: v.Configurations,
+	})
+}
+
+func (y *YamlSerializer) Deserialize(body []byte, target *Configuration) error {
+	var config yamlConfiguration
+	if err := yaml.Unmarshal(body, &config); err != nil {
+		return err
+	}
+	target.NamespaceName = config.NamespaceName
+	target.ReleaseKey = config.ReleaseKey
+	target.NotificationId = config.NotificationId
+	target.Configurations = config.Configurations
+	return nil
+}
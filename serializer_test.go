@@ -0,0 +1,151 @@
+package goapollo
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+func goldenConfiguration() *Configuration {
+	return &Configuration{
+		NamespaceName:  "application",
+		ReleaseKey:     "20240101000000-abcdef",
+		NotificationId: 42,
+		Configurations: map[string]string{
+			"db.host":        "127.0.0.1",
+			"db.password":    `p@ss=word:#!\`,
+			"key with space": " leading and trailing ",
+			"greeting":       "你好，世界 😀",
+			"tabs":           "a\tb\r\nc",
+			"empty":          "",
+			"quote":          `say "hi" 'there'`,
+			"content":        "{\n  \"name\": \"goapollo\"\n}\n",
+			"中文键":            "值",
+		},
+	}
+}
+
+func TestSerializer_Golden(t *testing.T) {
+	serializers := map[string]Serializer{
+		"backup.properties": NewPropertiesSerializer(),
+		"backup.yaml":       NewYamlSerializer(),
+		"backup.toml":       NewTomlSerializer(),
+	}
+	for name, serializer := range serializers {
+		body, err := serializer.Serialize(goldenConfiguration())
+		if err != nil {
+			t.Fatalf("%s: 序列化失败 -> %s", name, err)
+		}
+		path := filepath.Join("testdata", name)
+		if *update {
+			if err := ioutil.WriteFile(path, body, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		golden, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(body, golden) {
+			t.Errorf("%s: 序列化结果与 golden 文件不一致 ->\n%s", name, body)
+		}
+
+		var config Configuration
+		if err := serializer.Deserialize(golden, &config); err != nil {
+			t.Fatalf("%s: 反序列化失败 -> %s", name, err)
+		}
+		if !reflect.DeepEqual(&config, goldenConfiguration()) {
+			t.Errorf("%s: 反序列化结果不正确 -> %+v", name, config)
+		}
+	}
+}
+
+func TestPropertiesSerializer_Java(t *testing.T) {
+	// Java 客户端写入的备份文件以及手工修改后常见的写法
+	body := []byte("#Persisted by DefaultConfig\n" +
+		"#Mon Jan 01 00:00:00 CST 2024\n" +
+		"! another comment\n" +
+		"name=\\u4E2D\\u6587\n" +
+		"  indented : value\n" +
+		"spaced    value with spaces\n" +
+		"multi=first \\\n" +
+		"      second\n" +
+		"escaped\\ key=a\\=b\n" +
+		"raw=中文\r\n" +
+		"emoji=\\uD83D\\uDE00\n" +
+		"novalue\n")
+	var config Configuration
+	if err := NewPropertiesSerializer().Deserialize(body, &config); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"name":        "中文",
+		"indented":    "value",
+		"spaced":      "value with spaces",
+		"multi":       "first second",
+		"escaped key": "a=b",
+		"raw":         "中文",
+		"emoji":       "😀",
+		"novalue":     "",
+	}
+	if !reflect.DeepEqual(config.Configurations, expected) {
+		t.Fatalf("解析 properties 结果不正确 -> %#v", config.Configurations)
+	}
+	if err := NewPropertiesSerializer().Deserialize([]byte("a=\\u12G4\n"), &config); err == nil {
+		t.Fatal("无效的 \\u 转义应返回错误")
+	}
+}
+
+func TestTomlSerializer_HandEdited(t *testing.T) {
+	body := []byte(`# 手工修改的备份文件
+namespace_name = 'application' # 注释
+release_key = "r1"
+notification_id = 1_000
+
+configurations.timeout = "100"
+
+[configurations]
+"db.host" = '10.0.0.1'
+db . port = "3306"
+redis."key.prefix" = 'app:'
+plain = "a\u00e9\U0001F600"
+literal = '''
+C:\path
+'''
+folded = """
+one \
+    two"""
+`)
+	var config Configuration
+	if err := NewTomlSerializer().Deserialize(body, &config); err != nil {
+		t.Fatal(err)
+	}
+	expected := &Configuration{
+		NamespaceName:  "application",
+		ReleaseKey:     "r1",
+		NotificationId: 1000,
+		Configurations: map[string]string{
+			"db.host":          "10.0.0.1",
+			"db.port":          "3306",
+			"redis.key.prefix": "app:",
+			"timeout":          "100",
+			"plain":            "aé😀",
+			"literal":          "C:\\path\n",
+			"folded":           "one two",
+		},
+	}
+	if !reflect.DeepEqual(&config, expected) {
+		t.Fatalf("解析 toml 结果不正确 -> %#v", config)
+	}
+
+	for _, invalid := range []string{"a = \"b", "a = [1]", "a = \"\\x\"", "a \"b\"", "a = \"b\" c", "a. = \"b\"", "[a.]"} {
+		if err := NewTomlSerializer().Deserialize([]byte(invalid), &config); err == nil {
+			t.Errorf("无效的 toml 应返回错误 -> %s", invalid)
+		}
+	}
+}
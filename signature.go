@@ -0,0 +1,42 @@
+package goapollo
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	timestampHeader     = "Timestamp"
+)
+
+// signature 使用 HmacSHA1 算法计算 Apollo 访问密钥签名.
+func signature(timestamp, pathWithQuery, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest 为请求添加访问密钥签名头，未配置密钥时不做处理.
+func signRequest(req *http.Request, appId, secret string) {
+	signRequestAt(req, appId, secret, time.Now())
+}
+
+// signRequestAt 使用指定的时间为请求签名.
+func signRequestAt(req *http.Request, appId, secret string, now time.Time) {
+	if secret == "" {
+		return
+	}
+	timestamp := strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+	pathWithQuery := req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		pathWithQuery += "?" + req.URL.RawQuery
+	}
+	req.Header.Set(authorizationHeader, fmt.Sprintf("Apollo %s:%s", appId, signature(timestamp, pathWithQuery, secret)))
+	req.Header.Set(timestampHeader, timestamp)
+}
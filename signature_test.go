@@ -0,0 +1,95 @@
+package goapollo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	const secret = "df23df3f59884980844ff3dada30fa97"
+
+	type signed struct {
+		path          string
+		authorization string
+		timestamp     string
+		pathWithQuery string
+	}
+	requests := make(chan signed, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pathWithQuery := r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			pathWithQuery += "?" + r.URL.RawQuery
+		}
+		requests <- signed{
+			path:          r.URL.Path,
+			authorization: r.Header.Get(authorizationHeader),
+			timestamp:     r.Header.Get(timestampHeader),
+			pathWithQuery: pathWithQuery,
+		}
+		if strings.HasPrefix(r.URL.Path, "/configs/") {
+			_, _ = w.Write([]byte(`{"appId":"SampleApp","cluster":"default","namespaceName":"application","configurations":{"timeout":"100"},"releaseKey":"20200101"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "SampleApp", "default")
+	c.SetAccessKeySecret(secret)
+	c.AddNamespaceWithSerializerWithPath("application", NewJsonSerializer(), "")
+
+	if _, err := c.sync("application"); err != nil {
+		t.Fatalf("同步配置失败 -> %s", err)
+	}
+	c.notification.Watch()
+	defer c.notification.Close()
+
+	seen := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for !seen["/configs/SampleApp/default/application"] || !seen["/notifications/v2"] {
+		select {
+		case req := <-requests:
+			expected := "Apollo SampleApp:" + signature(req.timestamp, req.pathWithQuery, secret)
+			if req.timestamp == "" || req.authorization != expected {
+				t.Fatalf("签名错误 -> %s - %s - %s", req.path, req.authorization, expected)
+			}
+			seen[req.path] = true
+		case <-timeout:
+			t.Fatalf("等待请求超时 -> %+v", seen)
+		}
+	}
+}
+
+// TestSignature_Vector 使用 Apollo Java 客户端测试中的签名样例.
+func TestSignature_Vector(t *testing.T) {
+	const (
+		timestamp     = "1576478257344"
+		pathWithQuery = "/configs/100004458/default/application?ip=10.0.0.1"
+		secret        = "df23df3f59884980844ff3dada30fa97"
+		expected      = "EoKyziXvKqzHgwx+ijDJwgVTDgE="
+	)
+	if actual := signature(timestamp, pathWithQuery, secret); actual != expected {
+		t.Fatalf("签名错误 -> %s", actual)
+	}
+
+	req := httptest.NewRequest("GET", "http://localhost"+pathWithQuery, nil)
+	signRequestAt(req, "100004458", secret, time.Unix(0, 1576478257344*int64(time.Millisecond)))
+	if v := req.Header.Get(authorizationHeader); v != "Apollo 100004458:"+expected {
+		t.Fatalf("Authorization 请求头错误 -> %s", v)
+	}
+	if v := req.Header.Get(timestampHeader); v != timestamp {
+		t.Fatalf("Timestamp 请求头错误 -> %s", v)
+	}
+}
+
+func TestSignature_NoSecret(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://localhost/configs/SampleApp/default/application", nil)
+	signRequest(req, "SampleApp", "")
+	if req.Header.Get(authorizationHeader) != "" || req.Header.Get(timestampHeader) != "" {
+		t.Fatalf("未配置密钥时不应签名")
+	}
+}
@@ -0,0 +1,405 @@
+package goapollo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlTable toml 备份文件中保存配置项的表名.
+const tomlTable = "configurations"
+
+// TomlSerializer 使用 toml 格式保存备份，配置项保存在 [configurations] 表中，多行的值使用多行字符串.
+// 只支持备份文件用到的字符串和整数，不依赖第三方库. 手工添加的点号分隔的键（如 db.host）各段用点号连接后作为配置项的键名.
+type TomlSerializer struct{}
+
+func NewTomlSerializer() *TomlSerializer {
+	return &TomlSerializer{}
+}
+
+func (t *TomlSerializer) Serialize(v *Configuration) ([]byte, error) {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "namespace_name = %s\n", quoteToml(v.NamespaceName))
+	_, _ = fmt.Fprintf(&buf, "release_key = %s\n", quoteToml(v.ReleaseKey))
+	if v.NotificationId != 0 {
+		_, _ = fmt.Fprintf(&buf, "notification_id = %d\n", v.NotificationId)
+	}
+	_, _ = fmt.Fprintf(&buf, "\n[%s]\n", tomlTable)
+
+	keys := make([]string, 0, len(v.Configurations))
+	for key := range v.Configurations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := v.Configurations[key]
+		if strings.Contains(value, "\n") {
+			_, _ = fmt.Fprintf(&buf, "%s = \"\"\"\n%s\"\"\"\n", tomlKey(key), escapeToml(value, true))
+		} else {
+			_, _ = fmt.Fprintf(&buf, "%s = %s\n", tomlKey(key), quoteToml(value))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// tomlKey 只由字母、数字、下划线和中划线组成的键不加引号，其余的键（包括带点号的键）使用字符串.
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return quoteToml(key)
+		}
+	}
+	return key
+}
+
+func quoteToml(s string) string {
+	return `"` + escapeToml(s, false) + `"`
+}
+
+// escapeToml 转义基本字符串，multiline 为 true 时保留换行.
+func escapeToml(s string, multiline bool) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			if multiline {
+				buf.WriteByte('\n')
+			} else {
+				buf.WriteString(`\n`)
+			}
+		case '\t':
+			buf.WriteByte('\t')
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				_, _ = fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func (t *TomlSerializer) Deserialize(body []byte, target *Configuration) error {
+	target.Configurations = make(map[string]string)
+	p := &tomlParser{input: strings.Replace(string(body), "\r\n", "\n", -1), line: 1}
+	table := ""
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil
+		}
+		if p.peek() == '[' {
+			p.pos++
+			p.skipSpace(false)
+			name, err := p.dottedKey()
+			if err != nil {
+				return p.error(err)
+			}
+			if !p.consume(']') {
+				return p.error(errors.New("表名缺少 ]"))
+			}
+			table = strings.Join(name, ".")
+		} else {
+			segments, err := p.dottedKey()
+			if err != nil {
+				return p.error(err)
+			}
+			// 顶层的 configurations.key 等同于 [configurations] 表中的 key
+			key, keyTable := strings.Join(segments, "."), table
+			if table == "" && len(segments) > 1 && segments[0] == tomlTable {
+				key, keyTable = strings.Join(segments[1:], "."), tomlTable
+			}
+			if !p.consume('=') {
+				return p.error(errors.New("键后缺少 ="))
+			}
+			p.skipSpace(false)
+			value, err := p.value()
+			if err != nil {
+				return p.error(err)
+			}
+			if err := assignToml(target, keyTable, key, value); err != nil {
+				return p.error(err)
+			}
+		}
+		// 同一行后面只能是注释
+		p.skipSpace(false)
+		if !p.eof() && p.peek() != '\n' {
+			return p.error(fmt.Errorf("无效的字符 -> %q", p.peek()))
+		}
+	}
+}
+
+func assignToml(target *Configuration, table, key, value string) error {
+	if table == tomlTable {
+		target.Configurations[key] = value
+		return nil
+	}
+	if table != "" {
+		return nil
+	}
+	switch key {
+	case "namespace_name":
+		target.NamespaceName = value
+	case "release_key":
+		target.ReleaseKey = value
+	case "notification_id":
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		target.NotificationId = id
+	}
+	return nil
+}
+
+type tomlParser struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (p *tomlParser) error(err error) error {
+	return fmt.Errorf("解析 toml 失败 -> 第 %d 行 - %s", p.line, err)
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace 跳过空白和注释，newline 为 true 时同时跳过换行.
+func (p *tomlParser) skipSpace(newline bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '\n' && newline:
+			p.pos++
+			p.line++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// dottedKey 解析点号分隔的键，返回各段的名称，结束后跳过空白.
+func (p *tomlParser) dottedKey() ([]string, error) {
+	var segments []string
+	for {
+		segment, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+		p.skipSpace(false)
+		if !p.consume('.') {
+			return segments, nil
+		}
+		p.skipSpace(false)
+	}
+}
+
+func (p *tomlParser) key() (string, error) {
+	if p.eof() {
+		return "", errors.New("缺少键")
+	}
+	switch p.peek() {
+	case '"':
+		return p.basicString()
+	case '\'':
+		return p.literalString()
+	}
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("无效的键 -> %q", p.input[start:start+1])
+	}
+	return p.input[start:p.pos], nil
+}
+
+func (p *tomlParser) value() (string, error) {
+	if p.eof() {
+		return "", errors.New("缺少值")
+	}
+	switch {
+	case strings.HasPrefix(p.input[p.pos:], `"""`):
+		return p.multilineString(`"""`)
+	case strings.HasPrefix(p.input[p.pos:], `'''`):
+		return p.multilineString(`'''`)
+	case p.peek() == '"':
+		return p.basicString()
+	case p.peek() == '\'':
+		return p.literalString()
+	}
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n#", p.peek()) < 0 {
+		p.pos++
+	}
+	value := strings.Replace(p.input[start:p.pos], "_", "", -1)
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("只支持字符串和整数 -> %s", p.input[start:p.pos])
+	}
+	return value, nil
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++
+	var buf strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return buf.String(), nil
+		case '\n':
+			return "", errors.New("字符串缺少结束的引号")
+		case '\\':
+			if err := p.escape(&buf); err != nil {
+				return "", err
+			}
+		default:
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errors.New("字符串缺少结束的引号")
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.input[p.pos:], "'\n")
+	if end < 0 || p.input[p.pos+end] != '\'' {
+		return "", errors.New("字符串缺少结束的引号")
+	}
+	s := p.input[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// multilineString 解析多行字符串，开始的引号后紧跟的换行会被忽略.
+func (p *tomlParser) multilineString(quote string) (string, error) {
+	p.pos += len(quote)
+	if p.consume('\n') {
+		p.line++
+	}
+	var buf strings.Builder
+	for !p.eof() {
+		if strings.HasPrefix(p.input[p.pos:], quote) {
+			// 结束引号前最多可以有两个引号
+			for n := 0; n < 2 && strings.HasPrefix(p.input[p.pos+1:], quote); n++ {
+				buf.WriteByte(quote[0])
+				p.pos++
+			}
+			p.pos += len(quote)
+			return buf.String(), nil
+		}
+		c := p.peek()
+		switch {
+		case c == '\\' && quote == `"""`:
+			// 行尾的反斜杠会删除换行和下一行开头的空白
+			rest := strings.TrimLeft(p.input[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") {
+				p.pos = len(p.input) - len(rest)
+				for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.escape(&buf); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errors.New("多行字符串缺少结束的引号")
+}
+
+func (p *tomlParser) escape(buf *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return errors.New("无效的转义")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		buf.WriteByte('\b')
+	case 't':
+		buf.WriteByte('\t')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'r':
+		buf.WriteByte('\r')
+	case '"':
+		buf.WriteByte('"')
+	case '\\':
+		buf.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.input) {
+			return errors.New("无效的 unicode 转义")
+		}
+		r, err := strconv.ParseUint(p.input[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return fmt.Errorf("无效的 unicode 转义 -> \\%c%s", c, p.input[p.pos:p.pos+size])
+		}
+		buf.WriteRune(rune(r))
+		p.pos += size
+	default:
+		return fmt.Errorf("无效的转义 -> \\%c", c)
+	}
+	return nil
+}